@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,9 +14,54 @@ type Config struct {
 }
 
 type Telegram struct {
-	Token     string `yaml:"token"`
-	Debug     bool   `yaml:"debug"`
-	AdminUser int64  `yaml:"admin_user"`
+	Token     string    `yaml:"token"`
+	Debug     bool      `yaml:"debug"`
+	AdminUser int64     `yaml:"admin_user"`
+	Reminders Reminders `yaml:"reminders"`
+	Reports   Reports   `yaml:"reports"`
+
+	// APIEndpoint replaces the Telegram Bot API address, as a format with
+	// the token and method like the default https://api.telegram.org/bot%s/%s
+	APIEndpoint string `yaml:"api_endpoint"`
+
+	Conversations Conversations `yaml:"conversations"`
+	Webhook       Webhook       `yaml:"webhook"`
+}
+
+// Webhook configures Telegram to push the updates to URL instead of the bot
+// polling them, disabled when URL is empty. The server listens on Listen and
+// only accepts requests carrying Secret in the secret token header. It serves
+// HTTPS with the Cert and Key files, without them TLS must be terminated by a
+// proxy forwarding to Listen
+type Webhook struct {
+	URL    string `yaml:"url"`
+	Listen string `yaml:"listen"`
+	Secret string `yaml:"secret"`
+	Cert   string `yaml:"cert"`
+	Key    string `yaml:"key"`
+}
+
+// Conversations configures the guided flows, flows without an answer for
+// Timeout are cancelled. Persist keeps the flows in the database so they are
+// resumed after a restart
+type Conversations struct {
+	Timeout time.Duration `yaml:"timeout"`
+	Persist bool          `yaml:"persist"`
+}
+
+// Reports configures the monthly report posted on the first day of each
+// month about the previous one, disabled when ChatID is zero
+type Reports struct {
+	ChatID int64 `yaml:"chat_id"`
+}
+
+// Reminders configures the messages sent to users with unpaid billings,
+// starting DaysBefore the due date up to DaysAfter it, once every Cadence
+type Reminders struct {
+	Enabled    bool          `yaml:"enabled"`
+	DaysBefore int           `yaml:"days_before"`
+	DaysAfter  int           `yaml:"days_after"`
+	Cadence    time.Duration `yaml:"cadence"`
 }
 
 type Database struct {
@@ -0,0 +1,158 @@
+// Package chart renders simple PNG charts using only the standard image
+// packages and the basic bitmap font
+package chart
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	foreground = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	gridColor  = color.RGBA{0xdd, 0xdd, 0xdd, 0xff}
+
+	// Palette is used for series without color
+	Palette = []color.RGBA{
+		{0xe0, 0x5a, 0x47, 0xff},
+		{0x4c, 0xaf, 0x50, 0xff},
+		{0x21, 0x96, 0xf3, 0xff},
+		{0xff, 0xc1, 0x07, 0xff},
+	}
+)
+
+const (
+	margin     = 40
+	lineHeight = 16
+	gridLines  = 5
+)
+
+// Series is a group of values drawn with the same color, one per label
+type Series struct {
+	Name   string
+	Color  color.RGBA
+	Values []float64
+}
+
+// BarChart draws the series side by side for every label
+type BarChart struct {
+	Title  string
+	Labels []string
+	Series []Series
+	Width  int
+	Height int
+}
+
+// Render writes the chart as a PNG image
+func (c *BarChart) Render(w io.Writer) error {
+	if len(c.Labels) == 0 || len(c.Series) == 0 {
+		return fmt.Errorf("chart has no data")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	drawText(img, margin, margin/2+lineHeight/2, c.Title, foreground)
+
+	// Plot area leaves room for the title, the legend and the labels
+	left, top := margin*2, margin+lineHeight
+	right, bottom := c.Width-margin, c.Height-margin-lineHeight*2
+	if right <= left || bottom <= top {
+		return fmt.Errorf("chart size too small")
+	}
+
+	maxValue := 0.0
+	for _, series := range c.Series {
+		for _, value := range series.Values {
+			maxValue = math.Max(maxValue, value)
+		}
+	}
+	if maxValue == 0 {
+		maxValue = 1
+	}
+
+	for i := 0; i <= gridLines; i++ {
+		y := bottom - (bottom-top)*i/gridLines
+		fill(img, left, y, right, y+1, gridColor)
+		drawText(img, margin/4, y+4, formatValue(maxValue*float64(i)/gridLines), foreground)
+	}
+
+	group := (right - left) / len(c.Labels)
+	bar := max(group*3/4/len(c.Series), 1)
+	for i, label := range c.Labels {
+		x := left + group*i + (group-bar*len(c.Series))/2
+
+		for j, series := range c.Series {
+			value := 0.0
+			if i < len(series.Values) {
+				value = series.Values[i]
+			}
+
+			height := int(float64(bottom-top) * value / maxValue)
+			fill(img, x+bar*j, bottom-height, x+bar*(j+1)-1, bottom, c.color(j))
+		}
+
+		label = truncate(label, group/7)
+		drawText(img, left+group*i+(group-len([]rune(label))*7)/2, bottom+lineHeight, label, foreground)
+	}
+
+	// Legend
+	x := left
+	for j, series := range c.Series {
+		y := c.Height - margin/2
+		fill(img, x, y-10, x+10, y, c.color(j))
+		drawText(img, x+14, y, series.Name, foreground)
+		x += 14 + len(series.Name)*7 + margin/2
+	}
+
+	return png.Encode(w, img)
+}
+
+func (c *BarChart) color(i int) color.RGBA {
+	if c.Series[i].Color != (color.RGBA{}) {
+		return c.Series[i].Color
+	}
+	return Palette[i%len(Palette)]
+}
+
+func fill(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	draw.Draw(img, image.Rect(x0, y0, x1, y1), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func drawText(img *image.RGBA, x, y int, text string, c color.Color) {
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(text)
+}
+
+// truncate limits the text to size characters
+func truncate(text string, size int) string {
+	runes := []rune(text)
+	if size < 2 || len(runes) <= size {
+		return text
+	}
+	return string(runes[:size-1]) + "."
+}
+
+func formatValue(value float64) string {
+	switch {
+	case value >= 1e6:
+		return fmt.Sprintf("%.1fM", value/1e6)
+	case value >= 1e3:
+		return fmt.Sprintf("%.1fk", value/1e3)
+	}
+	return fmt.Sprintf("%.0f", value)
+}
@@ -0,0 +1,79 @@
+// Package cli implements the subcommands of the misaki binary that run
+// without starting the bot
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"misaki/config"
+	"misaki/internal/export"
+	"misaki/internal/repository"
+	"misaki/internal/service"
+	"misaki/logger"
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// Export writes the billings spreadsheet to a file or to the standard output
+//
+//	misaki export [-chat <chat-id>] [-billing <billing-identifier>] [-format csv|xlsx] [-output <file>]
+func Export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	chatID := flags.Int64("chat", 0, "telegram chat id of the group, all groups when empty")
+	id := flags.String("billing", "", "billing id or name, all billings when empty")
+	rawFormat := flags.String("format", "csv", "spreadsheet format: csv or xlsx")
+	output := flags.String("output", "", "output file, standard output when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := export.ParseFormat(*rawFormat)
+	if err != nil {
+		return err
+	}
+
+	conf, err := config.NewConfig()
+	if err != nil {
+		return err
+	}
+
+	log, err := logger.NewLogger()
+	if err != nil {
+		return err
+	}
+	defer log.Sync()
+
+	repo, err := repository.NewSQLite(conf, log)
+	if err != nil {
+		return err
+	}
+	s := service.NewService(log, repo)
+
+	var billing *types.Billing
+	if *id != "" {
+		billing = &types.Billing{Name: *id, ChatID: *chatID}
+		if billingID, err := uuid.Parse(*id); err == nil {
+			billing = &types.Billing{ID: billingID, ChatID: *chatID}
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := s.ExportBillings(context.Background(), w, *chatID, billing, format); err != nil {
+		return fmt.Errorf("export billings: %w", err)
+	}
+	return nil
+}
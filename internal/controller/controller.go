@@ -2,8 +2,11 @@ package controller
 
 import (
 	"context"
+	"net/http"
 
+	"misaki/config"
 	"misaki/internal/controller/telegram"
+	"misaki/internal/service"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.uber.org/fx"
@@ -12,12 +15,21 @@ import (
 
 type controller struct {
 	logger      *zap.Logger
+	config      *config.Config
+	service     *service.Service
 	telegramBot *telegram.TelegramBot
+	stopJobs    context.CancelFunc
+
+	// Server receiving the updates in webhook mode
+	webhook        *http.Server
+	webhookUpdates chan tgbotapi.Update
 }
 
-func NewController(logger *zap.Logger, telegramBot *telegram.TelegramBot) *controller {
+func NewController(config *config.Config, logger *zap.Logger, s *service.Service, telegramBot *telegram.TelegramBot) *controller {
 	return &controller{
 		logger:      logger,
+		config:      config,
+		service:     s,
 		telegramBot: telegramBot,
 	}
 }
@@ -29,11 +41,13 @@ func Start(lc fx.Lifecycle, c *controller) {
 			if err := c.StartTelegramBot(); err != nil {
 				return err
 			}
+			c.StartJobs()
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
 			log.Infow("Shutting down bot")
-			return nil
+			c.StopJobs()
+			return c.StopWebhook(ctx)
 		},
 	})
 }
@@ -44,18 +58,31 @@ func (c *controller) StartTelegramBot() error {
 	}
 
 	c.telegramBot.RegisterRoutes()
+	if err := c.telegramBot.LoadConversations(context.Background()); err != nil {
+		return err
+	}
+
+	if c.config.Telegram.Webhook.URL != "" {
+		return c.StartWebhook()
+	}
+
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
-	updates := c.telegramBot.Bot.GetUpdatesChan(u)
-
-	go func() {
-		for update := range updates {
-			if update.Message != nil {
-				c.telegramBot.Handle(update.Message)
-			}
-		}
-	}()
+	go c.handleUpdates(c.telegramBot.Bot.GetUpdatesChan(u))
 
 	return nil
 }
+
+// handleUpdates runs the handlers of each update, one at a time, until the
+// channel is closed
+func (c *controller) handleUpdates(updates tgbotapi.UpdatesChannel) {
+	for update := range updates {
+		if update.Message != nil {
+			c.telegramBot.Handle(update.Message)
+		}
+		if update.CallbackQuery != nil {
+			c.telegramBot.HandleCallback(update.CallbackQuery)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	billingCyclesInterval = time.Hour
+	remindersInterval     = time.Hour
+	reportsInterval       = time.Hour
+	budgetAlertsInterval  = time.Hour
+	conversationsInterval = time.Minute
+)
+
+// StartJobs runs the background jobs until StopJobs is called
+func (c *controller) StartJobs() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stopJobs = cancel
+
+	go c.runJob(ctx, "billing cycles", billingCyclesInterval, c.openBillingCycles)
+	go c.runJob(ctx, "budget alerts", budgetAlertsInterval, c.telegramBot.SendBudgetAlerts)
+	go c.runJob(ctx, "expired conversations", conversationsInterval, c.telegramBot.ExpireConversations)
+
+	if c.config.Telegram.Reminders.Enabled {
+		go c.runJob(ctx, "payment reminders", remindersInterval, c.telegramBot.SendReminders)
+	}
+
+	if c.config.Telegram.Reports.ChatID != 0 {
+		go c.runJob(ctx, "monthly reports", reportsInterval, c.telegramBot.PostMonthlyReport)
+	}
+}
+
+func (c *controller) StopJobs() {
+	if c.stopJobs != nil {
+		c.stopJobs()
+	}
+}
+
+// runJob executes job right away and then once every interval
+func (c *controller) runJob(ctx context.Context, name string, interval time.Duration, job func(context.Context)) {
+	c.logger.Info("Starting job", zap.String("job", name), zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		job(ctx)
+
+		select {
+		case <-ctx.Done():
+			c.logger.Info("Stopping job", zap.String("job", name))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *controller) openBillingCycles(ctx context.Context) {
+	opened, err := c.service.OpenBillingCycles(ctx, time.Now())
+	if err != nil {
+		c.logger.Error("error opening billing cycles", zap.Error(err))
+		return
+	}
+
+	if len(opened) > 0 {
+		c.logger.Info("Billing cycles opened", zap.Int("count", len(opened)))
+	}
+}
@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"misaki/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Purge permanently deletes the billings and users deleted more than the
+// informed number of days ago
+func (b *TelegramBot) Purge(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Fields(m.CommandArguments())
+
+	retention := service.DefaultPurgeRetention
+	if len(data) > 1 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: [retention-days]")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	if len(data) == 1 {
+		days, err := strconv.Atoi(data[0])
+		if err != nil || days < 0 {
+			msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Invalid retention, expected number of days, received: %s", data[0]))
+			msg.ReplyToMessageID = m.MessageID
+			if _, err := b.Bot.Send(msg); err != nil {
+				b.logger.Error("error while sending message", zap.Error(err))
+			}
+			return
+		}
+		retention = time.Duration(days) * 24 * time.Hour
+	}
+
+	billings, users, err := b.service.PurgeDeleted(ctx, retention)
+	if err != nil {
+		b.logger.Error("failed to purge deleted rows", zap.Duration("retention", retention), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while purging deleted billings and users")
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	b.logger.Info("Deleted rows purged", zap.Int64("billings", billings), zap.Int64("users", users), zap.Int64("TelegramID", m.From.ID))
+
+	messageText := fmt.Sprintf(
+		"🗑 *Purge Completed*\n\n"+
+			"⏳ *Retention:* %d days\n"+
+			"🤑 *Billings Purged:* %d\n"+
+			"👤 *Users Purged:* %d\n",
+		int(retention.Hours()/24),
+		billings,
+		users,
+	)
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
@@ -0,0 +1,182 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// auditMessageLength keeps the audit messages under the Telegram limit
+const auditMessageLength = 3500
+
+func (b *TelegramBot) Audit(ctx context.Context, m *tgbotapi.Message) {
+	filter, err := b.parseAuditFilter(ctx, m)
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s, expected: [user=<id>] [billing=<id>] [limit]", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	entries, err := b.service.ListAuditEntries(ctx, filter)
+	if err != nil {
+		b.logger.Error("failed to list audit entries", zap.Int64("ChatID", m.Chat.ID), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while listing audit entries")
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := "🕵️ *Audit Log*\n\n"
+	if len(entries) == 0 {
+		messageText += "No entries found"
+	}
+
+	actors := map[int64]string{}
+	for _, entry := range entries {
+		text := b.formatAuditEntry(ctx, entry, actors)
+
+		// Long logs are split in several messages
+		if len(messageText)+len(text) > auditMessageLength {
+			b.sendAudit(m, messageText)
+			messageText = ""
+		}
+		messageText += text
+	}
+
+	b.sendAudit(m, messageText)
+}
+
+// parseAuditFilter reads the user and billing options and the limit, a
+// number without key
+func (b *TelegramBot) parseAuditFilter(ctx context.Context, m *tgbotapi.Message) (types.AuditFilter, error) {
+	filter := types.AuditFilter{ChatID: m.Chat.ID}
+
+	args := []string{}
+	for _, arg := range strings.Fields(m.CommandArguments()) {
+		if !strings.Contains(arg, "=") {
+			limit, err := strconv.Atoi(arg)
+			if err != nil || limit <= 0 {
+				return filter, fmt.Errorf("invalid limit %s", arg)
+			}
+			filter.Limit = limit
+			continue
+		}
+		args = append(args, arg)
+	}
+
+	options, err := b.parseOptions(args, "user", "billing")
+	if err != nil {
+		return filter, err
+	}
+
+	if id, ok := options["user"]; ok {
+		user, err := b.findUser(ctx, id)
+		if err != nil {
+			return filter, err
+		}
+		filter.UserID = user.UserID
+		filter.TelegramID = user.TelegramID
+	}
+
+	if id, ok := options["billing"]; ok {
+		billing, err := b.parseBillingIdentifier(m.Chat.ID, id)
+		if err != nil {
+			return filter, err
+		}
+
+		billing, err = b.service.GetBilling(ctx, billing)
+		if err == sql.ErrNoRows {
+			return filter, fmt.Errorf("billing %s not found", id)
+		}
+		if err != nil {
+			return filter, err
+		}
+		filter.BillingID = billing.ID
+	}
+
+	return filter, nil
+}
+
+func (b *TelegramBot) formatAuditEntry(ctx context.Context, entry types.AuditEntry, actors map[int64]string) string {
+	command := "bot"
+	if entry.Command != "" {
+		command = strings.TrimSpace("/" + entry.Command + " " + entry.Arguments)
+	}
+
+	text := fmt.Sprintf("📅 %s by `%s`\n⚙️ `%s` ➡️ `%s`\n",
+		entry.CreatedAt.Format("2006-01-02 15:04:05"),
+		b.auditActorName(ctx, entry.ActorTelegramID, actors),
+		b.formatAuditText(command),
+		entry.Action,
+	)
+
+	if entry.BillingName != "" {
+		text += fmt.Sprintf("🧾 *Billing:* `%s`\n", b.formatAuditText(entry.BillingName))
+	}
+	if entry.UserName != "" {
+		text += fmt.Sprintf("👤 *User:* `%s`\n", b.formatAuditText(entry.UserName))
+	} else if entry.UserID != uuid.Nil {
+		text += fmt.Sprintf("👤 *User:* `%s`\n", entry.UserID)
+	}
+	if entry.Details != "" {
+		text += fmt.Sprintf("📝 `%s`\n", b.formatAuditText(entry.Details))
+	}
+
+	if entry.Result == "ok" {
+		text += "✅ ok\n\n"
+	} else {
+		text += fmt.Sprintf("❌ `%s`\n\n", b.formatAuditText(entry.Result))
+	}
+
+	return text
+}
+
+// auditActorName returns the name of the user who ran the command, users
+// deleted since then are shown by their Telegram ID
+func (b *TelegramBot) auditActorName(ctx context.Context, telegramID int64, actors map[int64]string) string {
+	if telegramID == types.TELEGRAM_ID_EMPTY {
+		return "bot"
+	}
+
+	if name, ok := actors[telegramID]; ok {
+		return name
+	}
+
+	name := fmt.Sprintf("%d", telegramID)
+	if user, err := b.service.GetUser(ctx, &types.User{TelegramID: telegramID}); err == nil {
+		name = b.getUserName(user)
+	}
+	actors[telegramID] = name
+	return name
+}
+
+// formatAuditText keeps user input from breaking the markdown of the entry
+func (b *TelegramBot) formatAuditText(text string) string {
+	text = strings.NewReplacer("`", "'", "\n", " ").Replace(text)
+	if len([]rune(text)) > 80 {
+		text = string([]rune(text)[:80]) + "…"
+	}
+	return text
+}
+
+func (b *TelegramBot) sendAudit(m *tgbotapi.Message, text string) {
+	msg := tgbotapi.NewMessage(m.Chat.ID, text)
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
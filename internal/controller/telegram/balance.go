@@ -0,0 +1,138 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func (b *TelegramBot) Balances(ctx context.Context, m *tgbotapi.Message) {
+	balances, transfers, err := b.service.ComputeBalances(ctx, m.Chat.ID)
+	if err != nil {
+		b.logger.Error("failed to compute balances", zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while computing balances")
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	if len(balances) == 0 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, "✅ Everyone is settled up")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := "⚖️ *Balances*\n\n"
+	for _, balance := range balances {
+		messageText += fmt.Sprintf("👤 `%s`: %s\n",
+			b.getUserName(&balance.User),
+			b.formatBalance(balance.Amount, balance.Currency),
+		)
+	}
+
+	messageText += "\n🔄 *Transfers*\n\n"
+	for _, transfer := range transfers {
+		messageText += fmt.Sprintf("`%s` ➡️ `%s`: %s\n",
+			b.getUserName(&transfer.From),
+			b.getUserName(&transfer.To),
+			money.Format(transfer.Amount, transfer.Currency),
+		)
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+func (b *TelegramBot) Settle(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Fields(m.CommandArguments())
+
+	if len(data) != 1 {
+		b.logger.Error("invalid settle arguments", zap.Int("number arguments", len(data)))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <user-identifier>")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	user, err := b.service.GetUser(ctx, &types.User{TelegramID: m.From.ID})
+	if err != nil {
+		b.logger.Error("failed to get user", zap.Int64("TelegramID", m.From.ID), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while getting user")
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, "⚠️ User not found, use /user_add first")
+		}
+
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	counterparty, err := b.findUser(ctx, data[0])
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get user: %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	transfers, err := b.service.Settle(ctx, m.Chat.ID, user, counterparty)
+	if err != nil {
+		b.logger.Error("failed to settle debts", zap.String("counterparty", data[0]), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error while settling debts: %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := fmt.Sprintf("🤝 *Settled Up:* `%s` and `%s`\n\n",
+		b.getUserName(user),
+		b.getUserName(counterparty),
+	)
+	for _, transfer := range transfers {
+		messageText += fmt.Sprintf("`%s` ➡️ `%s`: %s\n",
+			b.getUserName(&transfer.From),
+			b.getUserName(&transfer.To),
+			money.Format(transfer.Amount, transfer.Currency),
+		)
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+// formatBalance shows whether the user has to receive or pay the amount
+func (b *TelegramBot) formatBalance(amount int64, currency string) string {
+	if amount < 0 {
+		return fmt.Sprintf("owes %s", money.Format(-amount, currency))
+	}
+	return fmt.Sprintf("receives %s", money.Format(amount, currency))
+}
@@ -7,16 +7,23 @@ import (
 	"strconv"
 	"strings"
 
+	"misaki/internal/money"
+	"misaki/internal/service"
 	"misaki/types"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+// billingsPerPage limits the billings of each /billing_list page, every
+// billing adds two rows of buttons
+const billingsPerPage = 5
+
 func (b *TelegramBot) GetBilling(ctx context.Context, m *tgbotapi.Message) {
 	id := m.CommandArguments()
 
-	billing, err := b.parseBillingIdentifier(id)
+	billing, err := b.parseBillingIdentifier(m.Chat.ID, id)
 	if err != nil {
 		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get billing: %s", err.Error()))
 		msg.ReplyToMessageID = m.MessageID
@@ -46,28 +53,90 @@ func (b *TelegramBot) GetBilling(ctx context.Context, m *tgbotapi.Message) {
 			"🆔 *ID:* `%s`\n"+
 			"💬 *Name:* `%s`\n"+
 			"👤 *Users Associated:* %d\n"+
-			"💰 *Value:* %.2f\n"+
-			"💸 *Value per User:* %.2f\n"+
-			"📅 *Created At:* %s\n\n",
+			"💰 *Value:* %s\n"+
+			"💸 *Value per User:* %s\n"+
+			"📅 *Created At:* %s\n"+
+			"⏰ *Due Date:* %s\n"+
+			"🏦 *Payer:* %s\n"+
+			"🗂 *Category:* %s\n"+
+			"🏷 *Tags:* %s\n"+
+			"🚨 *Late Fee:* %s\n\n",
 		billing.ID.String(),
 		billing.Name,
 		len(billing.Payments),
-		billing.Value,
-		billing.ValuePerUser,
+		money.Format(billing.Value, billing.Currency),
+		b.formatValuePerUser(billing),
 		billing.CreatedAt.Format("2006-01-02 15:04:05"),
+		b.formatDate(billing.DueAt),
+		b.formatPayer(billing),
+		b.formatCategory(billing),
+		b.formatTags(billing),
+		b.formatLateFee(billing),
 	)
 
+	if conversion := b.formatConversion(billing); conversion != "" {
+		messageText += conversion + "\n"
+	}
+
+	if billing.Unallocated > 0 {
+		messageText += fmt.Sprintf("⚠️ *Unallocated:* %s, shares don't sum up to the billing value\n\n", money.Format(billing.Unallocated, billing.Currency))
+	} else if billing.Unallocated < 0 {
+		messageText += fmt.Sprintf("⚠️ *Over Allocated:* %s, shares exceed the billing value\n\n", money.Format(-billing.Unallocated, billing.Currency))
+	}
+
+	if billing.Installments > 0 {
+		messageText += b.formatInstallments(billing)
+	} else if len(billing.Cycles) > 0 {
+		messageText += b.formatCycles(billing)
+	}
+
 	for _, payment := range billing.Payments {
 		paymentText := fmt.Sprintf(
 			"👤 *User:* `%s`\n"+
-				"💵 *Paid:* %t\n"+
-				"📅 *Paid At:* %s\n\n",
+				"📊 *Share:* %s\n"+
+				"💰 *Amount:* %s\n"+
+				"💵 *Paid:* %s\n"+
+				"⏳ *Remaining:* %s\n"+
+				"📌 *Status:* %s\n"+
+				"📅 *Paid At:* %s\n",
 			b.getUserName(&payment.UserInfo),
-			payment.Paid,
-			payment.PaidAt.Format("2006-01-02 15:04:05"),
+			b.formatShare(payment.Share, billing.Currency),
+			money.Format(payment.Amount, billing.Currency),
+			money.Format(payment.PaidAmount, billing.Currency),
+			money.Format(payment.Outstanding, billing.Currency),
+			b.formatPaymentStatus(payment.Status),
+			b.formatDate(payment.PaidAt),
 		)
 
-		messageText += paymentText
+		if payment.Penalty.Due > 0 {
+			paymentText += fmt.Sprintf(
+				"🚨 *Late Fee:* %s (%d days late: fee %s + interest %s - paid %s)\n"+
+					"💳 *Amount Due:* %s\n",
+				money.Format(payment.Penalty.Due, billing.Currency),
+				payment.Penalty.DaysLate,
+				money.Format(payment.Penalty.Fee, billing.Currency),
+				money.Format(payment.Penalty.Interest, billing.Currency),
+				money.Format(payment.Penalty.Paid, billing.Currency),
+				money.Format(payment.AmountDue, billing.Currency),
+			)
+		} else if payment.Penalty.Paid > 0 {
+			paymentText += fmt.Sprintf("🚨 *Late Fee Paid:* %s\n", money.Format(payment.Penalty.Paid, billing.Currency))
+		}
+
+		for _, entry := range payment.Entries {
+			paymentText += fmt.Sprintf("  🧾 %s %s %s%s\n",
+				entry.CreatedAt.Format("2006-01-02"),
+				b.formatEntryAmount(entry.Amount, billing.Currency),
+				b.formatEntryFee(entry.Fee, billing.Currency),
+				tgbotapi.EscapeText(tgbotapi.ModeMarkdown, entry.Note),
+			)
+		}
+
+		messageText += paymentText + "\n"
+	}
+
+	if len(billing.Notes) > 0 {
+		messageText += b.formatNotes(billing)
 	}
 
 	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
@@ -75,11 +144,24 @@ func (b *TelegramBot) GetBilling(ctx context.Context, m *tgbotapi.Message) {
 	if _, err := b.Bot.Send(msg); err != nil {
 		b.logger.Error("error while sending message", zap.Error(err))
 	}
+
+	b.sendNoteFiles(m, billing)
+	b.sendPixCharge(ctx, m, billing)
 	return
 }
 
 func (b *TelegramBot) ListBillings(ctx context.Context, m *tgbotapi.Message) {
-	billings, err := b.service.ListBillings(ctx)
+	filter, err := b.parseBillingFilter(ctx, m)
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	billings, err := b.service.ListBillings(ctx, filter)
 	if err != nil {
 		b.logger.Error("failed to list billings", zap.Error(err))
 
@@ -91,36 +173,133 @@ func (b *TelegramBot) ListBillings(ctx context.Context, m *tgbotapi.Message) {
 		return
 	}
 
+	messageText, keyboard := b.formatBillingPage(billings, 0)
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	// Pages read the filters again from the command
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if keyboard != nil {
+		msg.ReplyMarkup = keyboard
+	}
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+	return
+}
+
+// formatBillingPage lists a page of the billings with buttons to act on each
+// of them, the keyboard is nil when there are no billings
+func (b *TelegramBot) formatBillingPage(billings []*types.Billing, page int) (string, *tgbotapi.InlineKeyboardMarkup) {
+	pages := max((len(billings)+billingsPerPage-1)/billingsPerPage, 1)
+	page = min(max(page, 0), pages-1)
+
 	messageText := fmt.Sprintf(
-		"🤑 *Billings Found:* %d\n\n",
+		"🤑 *Billings Found:* %d\n"+
+			"📄 *Page:* %d/%d\n\n",
 		len(billings),
+		page+1,
+		pages,
 	)
 
-	for _, billing := range billings {
-		text := fmt.Sprintf("🆔 `%s` \n💬 `%s` \n💸 %.2f \n\n",
+	rows := [][]tgbotapi.InlineKeyboardButton{}
+	for _, billing := range billings[page*billingsPerPage : min((page+1)*billingsPerPage, len(billings))] {
+		text := fmt.Sprintf("🆔 `%s` \n💬 `%s` \n💸 %s \n",
 			billing.ID,
 			billing.Name,
-			billing.Value,
+			money.Format(billing.Value, billing.Currency),
 		)
+		if !billing.DueAt.IsZero() {
+			text += fmt.Sprintf("⏰ %s \n", b.formatDate(billing.DueAt))
+		}
+		if billing.Recurrence != types.RecurrenceNone {
+			text += fmt.Sprintf("🔁 %s \n", b.formatRecurrence(billing))
+		}
+		if billing.Installments > 0 {
+			text += fmt.Sprintf("💳 %d/%d (%d/%d paid) \n", billing.Cycle, billing.Installments, billing.InstallmentsPaid, billing.Installments)
+		}
+		if billing.Category != "" || len(billing.Tags) > 0 {
+			text += fmt.Sprintf("🗂 %s 🏷 %s \n", b.formatCategory(billing), b.formatTags(billing))
+		}
+		text += "\n"
 
 		messageText += text
+
+		id := billing.ID.String()
+		rows = append(rows,
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔎 "+billing.Name, callbackData("billing_view", id)),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("💸 Pay", callbackData("billing_pay", id)),
+				tgbotapi.NewInlineKeyboardButtonData("↩️ Unpay", callbackData("billing_unpay", id)),
+				tgbotapi.NewInlineKeyboardButtonData("🙋 Join", callbackData("billing_join", id)),
+			),
+		)
 	}
 
-	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
-	msg.ParseMode = tgbotapi.ModeMarkdown
-	if _, err := b.Bot.Send(msg); err != nil {
-		b.logger.Error("error while sending message", zap.Error(err))
+	navigation := []tgbotapi.InlineKeyboardButton{}
+	if page > 0 {
+		navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData("⬅️ Previous", callbackData("billing_page", strconv.Itoa(page-1))))
 	}
-	return
+	if page < pages-1 {
+		navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData("Next ➡️", callbackData("billing_page", strconv.Itoa(page+1))))
+	}
+	if len(navigation) > 0 {
+		rows = append(rows, navigation)
+	}
+
+	if len(rows) == 0 {
+		return messageText, nil
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return messageText, &keyboard
+}
+
+// parseBillingFilter reads the /billing_list filters: category:<name>,
+// tag:<name>, unpaid and mine
+func (b *TelegramBot) parseBillingFilter(ctx context.Context, m *tgbotapi.Message) (types.BillingFilter, error) {
+	filter := types.BillingFilter{ChatID: m.Chat.ID}
+
+	for _, arg := range strings.Fields(m.CommandArguments()) {
+		key, value, _ := strings.Cut(arg, ":")
+		switch {
+		case key == "category" && value != "":
+			filter.Category = value
+		case key == "tag" && value != "":
+			filter.Tag = value
+		case arg == "unpaid":
+			filter.Unpaid = true
+		case arg == "mine":
+			user, err := b.service.GetUser(ctx, &types.User{TelegramID: m.From.ID})
+			if err == sql.ErrNoRows {
+				return filter, fmt.Errorf("user not found, use /user_add first")
+			}
+			if err != nil {
+				return filter, err
+			}
+			filter.UserID = user.UserID
+		default:
+			return filter, fmt.Errorf("invalid filter %s, expected: [category:<name>] [tag:<name>] [unpaid] [mine]", arg)
+		}
+	}
+
+	return filter, nil
 }
 
 func (b *TelegramBot) CreateBilling(ctx context.Context, m *tgbotapi.Message) {
+	// Without arguments the billing is created answering the wizard
+	if strings.TrimSpace(m.CommandArguments()) == "" {
+		b.startConversation(ctx, m, "billing_add")
+		return
+	}
+
 	data := strings.Split(m.CommandArguments(), " ")
 
-	if len(data) != 2 {
+	if len(data) < 2 {
 		b.logger.Error("invalid billing arguments", zap.Int("number arguments", len(data)))
 
-		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <name> <value>")
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <name> <value> [currency=<ISO-4217>] [due=<YYYY-MM-DD>] [payer=<user-identifier>] [category=<name>] [tags=<tag,...>] [recurrence=<weekly|monthly|yearly|day:N>] [installments=<N>] [fee=<value>] [interest=<N>%] [cap=<value>]")
 		msg.ReplyToMessageID = m.MessageID
 		if _, err := b.Bot.Send(msg); err != nil {
 			b.logger.Error("error while sending message", zap.Error(err))
@@ -129,11 +308,32 @@ func (b *TelegramBot) CreateBilling(ctx context.Context, m *tgbotapi.Message) {
 	}
 
 	name := data[0]
-	value, err := strconv.ParseFloat(data[1], 64)
+
+	options, err := b.parseOptions(data[2:], "currency", "due", "payer", "category", "tags", "recurrence", "installments", "fee", "interest", "cap")
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	currency, err := money.NormalizeCurrency(options["currency"])
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	value, err := money.Parse(data[1], currency)
 	if err != nil {
 		b.logger.Error("invalid billing value", zap.String("value", data[1]), zap.Error(err))
 
-		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Invalid value for billing, expected float, received: %s", data[1]))
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Invalid value for billing, expected decimal, received: %s", data[1]))
 		msg.ReplyToMessageID = m.MessageID
 		if _, err := b.Bot.Send(msg); err != nil {
 			b.logger.Error("error while sending message", zap.Error(err))
@@ -142,16 +342,81 @@ func (b *TelegramBot) CreateBilling(ctx context.Context, m *tgbotapi.Message) {
 	}
 
 	newBilling := &types.Billing{
-		Name:  name,
-		Value: value,
+		Name:     name,
+		Value:    value,
+		Currency: currency,
+		ChatID:   m.Chat.ID,
+	}
+
+	update, err := b.parseBillingUpdate(ctx, options, &types.BillingUpdate{})
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
 	}
 
+	if update.DueAt != nil {
+		newBilling.DueAt = *update.DueAt
+	}
+	if update.PayerID != nil {
+		newBilling.PayerID = *update.PayerID
+	}
+	if update.Category != nil {
+		newBilling.Category = *update.Category
+	}
+	if update.Tags != nil {
+		newBilling.Tags = *update.Tags
+	}
+
+	lateFee, err := b.parseLateFee(options, currency, types.LateFee{})
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+	if lateFee != nil {
+		newBilling.LateFee = *lateFee
+	}
+
+	if rule, ok := options["recurrence"]; ok {
+		newBilling.Recurrence, newBilling.RecurrenceDay, err = service.ParseRecurrence(rule)
+		if err != nil {
+			msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+			msg.ReplyToMessageID = m.MessageID
+			if _, err := b.Bot.Send(msg); err != nil {
+				b.logger.Error("error while sending message", zap.Error(err))
+			}
+			return
+		}
+	}
+
+	if installments, ok := options["installments"]; ok {
+		newBilling.Installments, err = strconv.Atoi(installments)
+		if err != nil {
+			msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Invalid number of installments, expected integer, received: %s", installments))
+			msg.ReplyToMessageID = m.MessageID
+			if _, err := b.Bot.Send(msg); err != nil {
+				b.logger.Error("error while sending message", zap.Error(err))
+			}
+			return
+		}
+	}
+
+	// The billing value becomes the first installment value
+	total := newBilling.Value
+
 	billing, err := b.service.CreateBilling(ctx, newBilling)
 	if err != nil {
 
-		b.logger.Error("error creating billing", zap.String("name", newBilling.Name), zap.Float64("value", newBilling.Value), zap.Error(err))
+		b.logger.Error("error creating billing", zap.String("name", newBilling.Name), zap.Int64("value", newBilling.Value), zap.Error(err))
 
-		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error creating billing %s (%f)", newBilling.Name, newBilling.Value))
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error creating billing %s (%s): %s", newBilling.Name, money.Format(total, newBilling.Currency), err.Error()))
 		msg.ReplyToMessageID = m.MessageID
 		if _, err := b.Bot.Send(msg); err != nil {
 			b.logger.Error("error while sending message", zap.Error(err))
@@ -164,14 +429,40 @@ func (b *TelegramBot) CreateBilling(ctx context.Context, m *tgbotapi.Message) {
 			"💰 *Billing Details:*\n"+
 			"🆔 *ID:* `%s`\n"+
 			"💬 *Name:* `%s`\n"+
-			"💸 *Value:* %.2f\n"+
-			"📅 *Created At:* %s\n",
+			"💸 *Value:* %s\n"+
+			"📅 *Created At:* %s\n"+
+			"⏰ *Due Date:* %s\n"+
+			"🗂 *Category:* %s\n"+
+			"🏷 *Tags:* %s\n"+
+			"🚨 *Late Fee:* %s\n",
 		billing.ID.String(),
 		billing.Name,
-		billing.Value,
+		money.Format(billing.Value, billing.Currency),
 		billing.CreatedAt.Format("2006-01-02 15:04:05"),
+		b.formatDate(billing.DueAt),
+		b.formatCategory(billing),
+		b.formatTags(billing),
+		b.formatLateFee(billing),
 	)
 
+	if billing.Recurrence != types.RecurrenceNone {
+		messageText += fmt.Sprintf(
+			"🔁 *Recurrence:* %s\n"+
+				"📆 *Next Cycle:* %s\n",
+			b.formatRecurrence(billing),
+			billing.NextCycleAt.Format("2006-01-02"),
+		)
+	}
+
+	if billing.Installments > 0 {
+		messageText += fmt.Sprintf(
+			"💳 *Installments:* %d\n"+
+				"💰 *Total:* %s\n",
+			billing.Installments,
+			money.Format(total, billing.Currency),
+		)
+	}
+
 	// Send the response message
 	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
 	msg.ParseMode = tgbotapi.ModeMarkdown
@@ -179,12 +470,234 @@ func (b *TelegramBot) CreateBilling(ctx context.Context, m *tgbotapi.Message) {
 	if _, err := b.Bot.Send(msg); err != nil {
 		b.logger.Error("error while sending message", zap.Error(err))
 	}
+
+	b.SendBudgetAlerts(ctx)
+}
+
+func (b *TelegramBot) EditBilling(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Split(m.CommandArguments(), " ")
+
+	if len(data) < 2 {
+		b.logger.Error("invalid billing edit arguments", zap.Int("number arguments", len(data)))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <billing-identifier> [name=<name>] [value=<value>] [due=<YYYY-MM-DD|none>] [payer=<user-identifier|none>] [category=<name|none>] [tags=<tag,...|none>] [fee=<value>] [interest=<N>%] [cap=<value>]")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	billing, err := b.parseBillingIdentifier(m.Chat.ID, data[0])
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get billing: %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	options, err := b.parseOptions(data[1:], "name", "value", "due", "payer", "category", "tags", "fee", "interest", "cap")
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	billing, err = b.service.GetBilling(ctx, billing)
+	if err != nil {
+		b.logger.Error("failed to get billing", zap.String("ID", data[0]), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Internal error while getting billing: %s", data[0]))
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Billing %s not found", data[0]))
+		}
+
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	update, err := b.parseBillingUpdate(ctx, options, &types.BillingUpdate{})
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	if name, ok := options["name"]; ok {
+		update.Name = &name
+	}
+
+	// Values are informed in the currency of the billing
+	if rawValue, ok := options["value"]; ok {
+		value, err := money.Parse(rawValue, billing.Currency)
+		if err != nil {
+			msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Invalid value for billing, expected decimal, received: %s", rawValue))
+			msg.ReplyToMessageID = m.MessageID
+			if _, err := b.Bot.Send(msg); err != nil {
+				b.logger.Error("error while sending message", zap.Error(err))
+			}
+			return
+		}
+		update.Value = &value
+	}
+
+	update.LateFee, err = b.parseLateFee(options, billing.Currency, billing.LateFee)
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	actor, err := b.service.GetUser(ctx, &types.User{TelegramID: m.From.ID})
+	if err != nil {
+		b.logger.Error("failed to get user", zap.Int64("TelegramID", m.From.ID), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while getting user")
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	billing, err = b.service.UpdateBilling(ctx, billing, update, actor)
+	if err != nil {
+		b.logger.Error("failed to edit billing", zap.String("ID", data[0]), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error while editing billing %s: %s", data[0], err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := fmt.Sprintf(
+		"✏️ *Billing Edited*\n\n"+
+			"🆔 *ID:* `%s`\n"+
+			"💬 *Name:* `%s`\n"+
+			"💰 *Value:* %s\n"+
+			"💸 *Value per User:* %s\n"+
+			"⏰ *Due Date:* %s\n"+
+			"🏦 *Payer:* %s\n"+
+			"🗂 *Category:* %s\n"+
+			"🏷 *Tags:* %s\n"+
+			"🚨 *Late Fee:* %s\n",
+		billing.ID.String(),
+		billing.Name,
+		money.Format(billing.Value, billing.Currency),
+		b.formatValuePerUser(billing),
+		b.formatDate(billing.DueAt),
+		b.formatPayer(billing),
+		b.formatCategory(billing),
+		b.formatTags(billing),
+		b.formatLateFee(billing),
+	)
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+
+	b.SendBudgetAlerts(ctx)
+}
+
+// parseBillingUpdate fills update with the billing fields informed as options
+func (b *TelegramBot) parseBillingUpdate(ctx context.Context, options map[string]string, update *types.BillingUpdate) (*types.BillingUpdate, error) {
+	if due, ok := options["due"]; ok {
+		dueAt, err := b.parseDate(due)
+		if err != nil {
+			return nil, err
+		}
+		update.DueAt = &dueAt
+	}
+
+	if payer, ok := options["payer"]; ok {
+		payerID := uuid.Nil
+		if payer != "none" {
+			user, err := b.findUser(ctx, payer)
+			if err != nil {
+				return nil, err
+			}
+			payerID = user.UserID
+		}
+		update.PayerID = &payerID
+	}
+
+	if category, ok := options["category"]; ok {
+		if category == "none" {
+			category = ""
+		}
+		update.Category = &category
+	}
+
+	if rawTags, ok := options["tags"]; ok {
+		tags := []string{}
+		if rawTags != "none" {
+			tags = strings.Split(rawTags, ",")
+		}
+		update.Tags = &tags
+	}
+
+	return update, nil
+}
+
+// parseLateFee reads the fee, interest and cap options over the current
+// policy, values are informed in the currency of the billing. It returns nil
+// when no option was informed
+func (b *TelegramBot) parseLateFee(options map[string]string, currency string, policy types.LateFee) (*types.LateFee, error) {
+	informed := false
+
+	if fee, ok := options["fee"]; ok {
+		flat, err := money.Parse(fee, currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid late fee, expected decimal, received: %s", fee)
+		}
+		policy.Flat = flat
+		informed = true
+	}
+
+	if interest, ok := options["interest"]; ok {
+		rate, err := service.ParseLateFeeRate(interest)
+		if err != nil {
+			return nil, err
+		}
+		policy.DailyRate = rate
+		informed = true
+	}
+
+	if rawCap, ok := options["cap"]; ok {
+		limit, err := money.Parse(rawCap, currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid late fee cap, expected decimal, received: %s", rawCap)
+		}
+		policy.Cap = limit
+		informed = true
+	}
+
+	if !informed {
+		return nil, nil
+	}
+	return &policy, nil
 }
 
 func (b *TelegramBot) DeleteBilling(ctx context.Context, m *tgbotapi.Message) {
 	id := m.CommandArguments()
 
-	billing, err := b.parseBillingIdentifier(id)
+	billing, err := b.parseBillingIdentifier(m.Chat.ID, id)
 	if err != nil {
 		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get billing: %s", err.Error()))
 		msg.ReplyToMessageID = m.MessageID
@@ -199,6 +712,9 @@ func (b *TelegramBot) DeleteBilling(ctx context.Context, m *tgbotapi.Message) {
 		b.logger.Error("failed to delete billing", zap.String("ID", id), zap.Error(err))
 
 		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Internal error while deleting billing: %s", id))
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Billing %s not found", id))
+		}
 
 		if _, err := b.Bot.Send(msg); err != nil {
 			b.logger.Error("error while sending message", zap.Error(err))
@@ -206,7 +722,7 @@ func (b *TelegramBot) DeleteBilling(ctx context.Context, m *tgbotapi.Message) {
 		return
 	}
 
-	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("🤑 *Billing Deleted:* %s", id))
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("🤑 *Billing Deleted:* %s\n\nUse /billing\\_restore to undo it", id))
 	msg.ReplyToMessageID = m.MessageID
 	msg.ParseMode = tgbotapi.ModeMarkdown
 	if _, err := b.Bot.Send(msg); err != nil {
@@ -216,6 +732,42 @@ func (b *TelegramBot) DeleteBilling(ctx context.Context, m *tgbotapi.Message) {
 	return
 }
 
+func (b *TelegramBot) RestoreBilling(ctx context.Context, m *tgbotapi.Message) {
+	id := m.CommandArguments()
+
+	billing, err := b.parseBillingIdentifier(m.Chat.ID, id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get billing: %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	billing, err = b.service.RestoreBilling(ctx, billing)
+	if err != nil {
+		b.logger.Error("failed to restore billing", zap.String("ID", id), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error while restoring billing %s: %s", id, err.Error()))
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Billing %s not found", id))
+		}
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("🤑 *Billing Restored:* `%s`", billing.Name))
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
 func (b *TelegramBot) AssociatePayment(ctx context.Context, m *tgbotapi.Message) {
 	b.changePaymentAssociation(ctx, m, true)
 }
@@ -227,10 +779,15 @@ func (b *TelegramBot) DisassociatePayment(ctx context.Context, m *tgbotapi.Messa
 func (b *TelegramBot) changePaymentAssociation(ctx context.Context, m *tgbotapi.Message, associate bool) {
 	data := strings.Split(m.CommandArguments(), " ")
 
-	if len(data) != 2 {
+	usage := "<billing-identifier> <user-identifier>"
+	if associate {
+		usage += " [<N>%|fixed:<value>|weight:<N>]"
+	}
+
+	if len(data) != 2 && !(associate && len(data) == 3) {
 		b.logger.Error("invalid payment association", zap.Int("number arguments", len(data)))
 
-		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <billing-identifier> <user-identifier>")
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Invalid number of arguments received, expected: %s", usage))
 		msg.ReplyToMessageID = m.MessageID
 		if _, err := b.Bot.Send(msg); err != nil {
 			b.logger.Error("error while sending message", zap.Error(err))
@@ -238,7 +795,7 @@ func (b *TelegramBot) changePaymentAssociation(ctx context.Context, m *tgbotapi.
 		return
 	}
 	// Parse billing
-	billing, err := b.parseBillingIdentifier(data[0])
+	billing, err := b.parseBillingIdentifier(m.Chat.ID, data[0])
 	if err != nil {
 		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get billing: %s", err.Error()))
 		msg.ReplyToMessageID = m.MessageID
@@ -296,11 +853,23 @@ func (b *TelegramBot) changePaymentAssociation(ctx context.Context, m *tgbotapi.
 		UserID:    user.UserID,
 	}
 
+	if len(data) == 3 {
+		payment.Share, err = service.ParseShare(data[2], billing.Currency)
+		if err != nil {
+			msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+			msg.ReplyToMessageID = m.MessageID
+			if _, err := b.Bot.Send(msg); err != nil {
+				b.logger.Error("error while sending message", zap.Error(err))
+			}
+			return
+		}
+	}
+
 	if err := b.service.ChangePaymentAssociation(ctx, payment, associate); err != nil {
 
 		b.logger.Error("failed to change association", zap.Bool("Associate", associate), zap.Error(err))
 
-		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while changing payment association")
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error while changing payment association: %s", err.Error()))
 		if _, err := b.Bot.Send(msg); err != nil {
 			b.logger.Error("error while sending message", zap.Error(err))
 		}
@@ -321,6 +890,10 @@ func (b *TelegramBot) changePaymentAssociation(ctx context.Context, m *tgbotapi.
 		payment.BillingID,
 	)
 
+	if associate {
+		messageText += fmt.Sprintf("📊 *Share:* %s\n", b.formatShare(payment.Share, billing.Currency))
+	}
+
 	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
 	msg.ParseMode = tgbotapi.ModeMarkdown
 	if _, err := b.Bot.Send(msg); err != nil {
@@ -330,9 +903,10 @@ func (b *TelegramBot) changePaymentAssociation(ctx context.Context, m *tgbotapi.
 }
 
 func (b *TelegramBot) PayBilling(ctx context.Context, m *tgbotapi.Message) {
-	id := m.CommandArguments()
+	data := strings.Split(m.CommandArguments(), " ")
+
 	// Parse billing
-	billing, err := b.parseBillingIdentifier(id)
+	billing, err := b.parseBillingIdentifier(m.Chat.ID, data[0])
 	if err != nil {
 		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get billing: %s", err.Error()))
 		msg.ReplyToMessageID = m.MessageID
@@ -342,16 +916,22 @@ func (b *TelegramBot) PayBilling(ctx context.Context, m *tgbotapi.Message) {
 		return
 	}
 
-	user := &types.User{
-		TelegramID: m.From.ID,
+	amount, note := b.parsePaymentArgs(data[1:], true)
+
+	// Payments informed by the user require a receipt reviewed by an admin
+	if _, _, ok := b.parseProofFile(m); !ok {
+		b.requestPaymentProof(m)
+		return
 	}
-	b.changePaymentStatus(ctx, m, billing, user, true)
+
+	b.submitPaymentProof(ctx, m, billing, amount, note)
 }
 
 func (b *TelegramBot) UnpayBilling(ctx context.Context, m *tgbotapi.Message) {
-	id := m.CommandArguments()
+	data := strings.Split(m.CommandArguments(), " ")
+
 	// Parse billing
-	billing, err := b.parseBillingIdentifier(id)
+	billing, err := b.parseBillingIdentifier(m.Chat.ID, data[0])
 	if err != nil {
 		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get billing: %s", err.Error()))
 		msg.ReplyToMessageID = m.MessageID
@@ -361,19 +941,34 @@ func (b *TelegramBot) UnpayBilling(ctx context.Context, m *tgbotapi.Message) {
 		return
 	}
 
+	amount, note := b.parsePaymentArgs(data[1:], false)
+
 	user := &types.User{
 		TelegramID: m.From.ID,
 	}
-	b.changePaymentStatus(ctx, m, billing, user, false)
+	b.changePaymentStatus(ctx, m, billing, user, amount, note, false)
 }
 
 func (b *TelegramBot) PayBillingAdmin(ctx context.Context, m *tgbotapi.Message) {
+	b.changePaymentStatusAdmin(ctx, m, true)
+}
+
+func (b *TelegramBot) UnpayBillingAdmin(ctx context.Context, m *tgbotapi.Message) {
+	b.changePaymentStatusAdmin(ctx, m, false)
+}
+
+func (b *TelegramBot) changePaymentStatusAdmin(ctx context.Context, m *tgbotapi.Message, status bool) {
 	data := strings.Split(m.CommandArguments(), " ")
 
-	if len(data) != 2 {
+	if len(data) < 2 {
 		b.logger.Error("invalid payment association", zap.Int("number arguments", len(data)))
 
-		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <billing-identifier> <user-identifier>")
+		usage := "<billing-identifier> <user-identifier> [note]"
+		if status {
+			usage = "<billing-identifier> <user-identifier> [amount] [note]"
+		}
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Invalid number of arguments received, expected: %s", usage))
 		msg.ReplyToMessageID = m.MessageID
 		if _, err := b.Bot.Send(msg); err != nil {
 			b.logger.Error("error while sending message", zap.Error(err))
@@ -382,7 +977,7 @@ func (b *TelegramBot) PayBillingAdmin(ctx context.Context, m *tgbotapi.Message)
 	}
 
 	// Parse billing
-	billing, err := b.parseBillingIdentifier(data[0])
+	billing, err := b.parseBillingIdentifier(m.Chat.ID, data[0])
 	if err != nil {
 		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get billing: %s", err.Error()))
 		msg.ReplyToMessageID = m.MessageID
@@ -403,50 +998,26 @@ func (b *TelegramBot) PayBillingAdmin(ctx context.Context, m *tgbotapi.Message)
 		return
 	}
 
-	b.changePaymentStatus(ctx, m, billing, user, true)
-}
-
-func (b *TelegramBot) UnpayBillingAdmin(ctx context.Context, m *tgbotapi.Message) {
-	data := strings.Split(m.CommandArguments(), " ")
-
-	if len(data) != 2 {
-		b.logger.Error("invalid payment association", zap.Int("number arguments", len(data)))
-
-		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <billing-identifier> <user-identifier>")
-		msg.ReplyToMessageID = m.MessageID
-		if _, err := b.Bot.Send(msg); err != nil {
-			b.logger.Error("error while sending message", zap.Error(err))
-		}
-		return
-	}
+	amount, note := b.parsePaymentArgs(data[2:], status)
 
-	// Parse billing
-	billing, err := b.parseBillingIdentifier(data[0])
-	if err != nil {
-		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get billing: %s", err.Error()))
-		msg.ReplyToMessageID = m.MessageID
-		if _, err := b.Bot.Send(msg); err != nil {
-			b.logger.Error("error while sending message", zap.Error(err))
-		}
-		return
-	}
+	b.changePaymentStatus(ctx, m, billing, user, amount, note, status)
+}
 
-	// Parse user
-	user, err := b.parseUserIdentifier(data[1])
-	if err != nil {
-		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get user, invalid id informed: %s", data[1]))
-		msg.ReplyToMessageID = m.MessageID
-		if _, err := b.Bot.Send(msg); err != nil {
-			b.logger.Error("error while sending message", zap.Error(err))
-		}
-		return
+// parsePaymentArgs splits the optional [amount] [note] arguments of payment
+// commands, reverting payments only accepts a note. An argument that starts
+// like a number is always the amount, so a malformed amount is rejected when
+// parsed instead of paying the full outstanding value with it as note
+func (b *TelegramBot) parsePaymentArgs(args []string, withAmount bool) (string, string) {
+	amount := ""
+	if withAmount && len(args) > 0 && args[0] != "" && strings.ContainsAny(args[0][:1], "0123456789.,+-") {
+		amount = args[0]
+		args = args[1:]
 	}
 
-	b.changePaymentStatus(ctx, m, billing, user, false)
+	return amount, strings.TrimSpace(strings.Join(args, " "))
 }
 
-// TODO: If status is true, validate if payment exists before change
-func (b *TelegramBot) changePaymentStatus(ctx context.Context, m *tgbotapi.Message, billing *types.Billing, user *types.User, status bool) {
+func (b *TelegramBot) changePaymentStatus(ctx context.Context, m *tgbotapi.Message, billing *types.Billing, user *types.User, amount, note string, status bool) {
 	// Search billing
 	billing, err := b.service.GetBilling(ctx, billing)
 	if err != nil {
@@ -470,7 +1041,7 @@ func (b *TelegramBot) changePaymentStatus(ctx context.Context, m *tgbotapi.Messa
 
 		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while getting user")
 		if err == sql.ErrNoRows {
-			msg = tgbotapi.NewMessage(m.Chat.ID, "⚠️ User %s not found")
+			msg = tgbotapi.NewMessage(m.Chat.ID, "⚠️ User not found")
 		}
 
 		if _, err := b.Bot.Send(msg); err != nil {
@@ -479,14 +1050,26 @@ func (b *TelegramBot) changePaymentStatus(ctx context.Context, m *tgbotapi.Messa
 		return
 	}
 
-	payment := &types.Payment{
+	entry := &types.PaymentEntry{
 		BillingID: billing.ID,
 		UserID:    user.UserID,
-		Paid:      status,
+		Note:      note,
+	}
+
+	if amount != "" {
+		entry.Amount, err = money.Parse(amount, billing.Currency)
+		if err != nil || entry.Amount <= 0 {
+			msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Invalid amount, expected positive value, received: %s", amount))
+			msg.ReplyToMessageID = m.MessageID
+			if _, err := b.Bot.Send(msg); err != nil {
+				b.logger.Error("error while sending message", zap.Error(err))
+			}
+			return
+		}
 	}
 
 	// Payment Exist
-	exist, err := b.service.PaymentAssociationExist(ctx, payment)
+	exist, err := b.service.PaymentAssociationExist(ctx, &types.Payment{BillingID: billing.ID, UserID: user.UserID})
 	if err != nil {
 		b.logger.Error("failed to get check payment association", zap.Error(err))
 
@@ -499,7 +1082,7 @@ func (b *TelegramBot) changePaymentStatus(ctx context.Context, m *tgbotapi.Messa
 	}
 
 	if !exist {
-		b.logger.Error("payment association not found", zap.String("BillingID", payment.BillingID.String()), zap.String("UserID", payment.UserID.String()))
+		b.logger.Error("payment association not found", zap.String("BillingID", entry.BillingID.String()), zap.String("UserID", entry.UserID.String()))
 
 		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Payment association not found")
 
@@ -509,11 +1092,22 @@ func (b *TelegramBot) changePaymentStatus(ctx context.Context, m *tgbotapi.Messa
 		return
 	}
 
-	if err := b.service.ChangePaymentStatus(ctx, payment); err != nil {
+	// The user running the command is recorded as the one informing the payment
+	if actor, err := b.service.GetUser(ctx, &types.User{TelegramID: m.From.ID}); err == nil {
+		entry.RecordedBy = actor.UserID
+	}
+
+	var payment *types.Payment
+	if status {
+		payment, err = b.service.RecordPayment(ctx, entry)
+	} else {
+		payment, err = b.service.RevertPayment(ctx, entry)
+	}
+	if err != nil {
 
-		b.logger.Error("failed to change association", zap.Bool("Paid", status), zap.Error(err))
+		b.logger.Error("failed to change payment status", zap.Bool("Paid", status), zap.Error(err))
 
-		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while changing payment association")
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error while changing payment status: %s", err.Error()))
 		if _, err := b.Bot.Send(msg); err != nil {
 			b.logger.Error("error while sending message", zap.Error(err))
 		}
@@ -521,7 +1115,7 @@ func (b *TelegramBot) changePaymentStatus(ctx context.Context, m *tgbotapi.Messa
 	}
 
 	paidText := "💵 *Status:* Unpaid"
-	if status {
+	if payment.Paid {
 		paidText = fmt.Sprintf(
 			"💵 *Status:* Paid\n"+
 				"📅 *Paid At:* %s",
@@ -533,9 +1127,17 @@ func (b *TelegramBot) changePaymentStatus(ctx context.Context, m *tgbotapi.Messa
 		"🔄 *Billing Payment*\n\n"+
 			"👤 *User ID:* `%s`\n"+
 			"💸 *Billing ID:* `%s`\n"+
+			"🧾 *Recorded:* %s %s\n"+
+			"💰 *Paid:* %s of %s\n"+
+			"⏳ *Remaining:* %s\n"+
 			"%s\n",
-		payment.UserID,
-		payment.BillingID,
+		entry.UserID,
+		entry.BillingID,
+		b.formatEntryAmount(entry.Amount, billing.Currency),
+		b.formatEntryFee(entry.Fee, billing.Currency),
+		money.Format(payment.PaidAmount, billing.Currency),
+		money.Format(payment.Amount, billing.Currency),
+		money.Format(payment.AmountDue, billing.Currency),
 		paidText,
 	)
 
@@ -546,3 +1148,57 @@ func (b *TelegramBot) changePaymentStatus(ctx context.Context, m *tgbotapi.Messa
 	}
 	return
 }
+
+func (b *TelegramBot) formatCycles(billing *types.Billing) string {
+	// The root holds the rule and the date of the next cycle
+	root := billing
+	for _, cycle := range billing.Cycles {
+		if cycle.ParentID == uuid.Nil {
+			root = cycle
+			break
+		}
+	}
+
+	text := fmt.Sprintf(
+		"🔁 *Recurrence:* %s\n"+
+			"🔄 *Cycle:* %d\n"+
+			"📆 *Period Start:* %s\n"+
+			"📆 *Next Cycle:* %s\n"+
+			"🗂 *Cycles:*",
+		b.formatRecurrence(root),
+		billing.Cycle,
+		billing.PeriodStart.Format("2006-01-02"),
+		root.NextCycleAt.Format("2006-01-02"),
+	)
+
+	for _, cycle := range billing.Cycles {
+		text += fmt.Sprintf(" `%s`", cycle.Name)
+	}
+
+	return text + "\n\n"
+}
+
+func (b *TelegramBot) formatInstallments(billing *types.Billing) string {
+	var total int64
+	for _, installment := range billing.Cycles {
+		total += installment.Value
+	}
+
+	text := fmt.Sprintf(
+		"💳 *Installment:* %d/%d\n"+
+			"✅ *Progress:* %d/%d paid\n"+
+			"💰 *Plan Total:* %s\n"+
+			"🗂 *Installments:*",
+		billing.Cycle,
+		billing.Installments,
+		billing.InstallmentsPaid,
+		billing.Installments,
+		money.Format(total, billing.Currency),
+	)
+
+	for _, installment := range billing.Cycles {
+		text += fmt.Sprintf(" `%s`", installment.Name)
+	}
+
+	return text + "\n\n"
+}
@@ -0,0 +1,249 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	skipOption    = conversationOption{label: "⏭ Skip", value: "skip"}
+	confirmOption = conversationOption{label: "✅ Create", value: "yes"}
+	abortOption   = conversationOption{label: "❌ Cancel", value: "no"}
+)
+
+// billingWizard guides /billing_add without arguments through the name, value,
+// participants and due date of the billing before creating it
+func (b *TelegramBot) billingWizard() conversationFlow {
+	return conversationFlow{
+		first: "name",
+		steps: map[string]conversationStep{
+			"name": {
+				prompt: b.staticPrompt("💬 What is the name of the billing? Names cannot have spaces"),
+				handle: b.billingWizardName,
+			},
+			"value": {
+				prompt: b.staticPrompt("💸 What is the value? Add the currency after it to use another one, e.g. `12,50 USD`"),
+				handle: b.billingWizardValue,
+			},
+			"participants": {
+				prompt:  b.staticPrompt("👥 Who shares the billing? Reply with the user identifiers separated by spaces"),
+				options: []conversationOption{skipOption},
+				handle:  b.billingWizardParticipants,
+			},
+			"due": {
+				prompt:  b.staticPrompt("⏰ When is it due? Reply with the date as YYYY-MM-DD"),
+				options: []conversationOption{skipOption},
+				handle:  b.billingWizardDue,
+			},
+			"confirm": {
+				prompt:  b.billingWizardSummary,
+				options: []conversationOption{confirmOption, abortOption},
+				handle:  b.billingWizardConfirm,
+			},
+		},
+	}
+}
+
+func (b *TelegramBot) staticPrompt(text string) func(context.Context, *types.Conversation) string {
+	return func(context.Context, *types.Conversation) string {
+		return text
+	}
+}
+
+func (b *TelegramBot) billingWizardName(ctx context.Context, m *tgbotapi.Message, conversation *types.Conversation) (string, error) {
+	name := strings.TrimSpace(m.Text)
+	if err := b.service.CheckBillingName(ctx, m.Chat.ID, name); err != nil {
+		return "", err
+	}
+
+	conversation.Data["name"] = name
+	return "value", nil
+}
+
+func (b *TelegramBot) billingWizardValue(ctx context.Context, m *tgbotapi.Message, conversation *types.Conversation) (string, error) {
+	data := strings.Fields(m.Text)
+	if len(data) == 0 || len(data) > 2 {
+		return "", fmt.Errorf("invalid value, expected: <value> [currency]")
+	}
+
+	var currency string
+	if len(data) == 2 {
+		currency = data[1]
+	}
+	currency, err := money.NormalizeCurrency(currency)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := money.Parse(data[0], currency)
+	if err != nil || value < 0 {
+		return "", fmt.Errorf("invalid value for billing, expected decimal, received: %s", data[0])
+	}
+
+	conversation.Data["value"] = strconv.FormatInt(value, 10)
+	conversation.Data["currency"] = currency
+	return "participants", nil
+}
+
+func (b *TelegramBot) billingWizardParticipants(ctx context.Context, m *tgbotapi.Message, conversation *types.Conversation) (string, error) {
+	ids := []string{}
+	if answer := strings.TrimSpace(m.Text); answer != skipOption.value {
+		for _, id := range strings.Fields(answer) {
+			user, err := b.findUser(ctx, id)
+			if err != nil {
+				return "", err
+			}
+
+			if err := b.service.CheckMember(ctx, m.Chat.ID, user.UserID); err != nil {
+				return "", fmt.Errorf("%s: %s", id, err.Error())
+			}
+
+			if !slices.Contains(ids, user.UserID.String()) {
+				ids = append(ids, user.UserID.String())
+			}
+		}
+	}
+
+	conversation.Data["participants"] = strings.Join(ids, ",")
+	return "due", nil
+}
+
+func (b *TelegramBot) billingWizardDue(ctx context.Context, m *tgbotapi.Message, conversation *types.Conversation) (string, error) {
+	answer := strings.TrimSpace(m.Text)
+	if answer == skipOption.value {
+		conversation.Data["due"] = ""
+		return "confirm", nil
+	}
+
+	date, err := b.parseDate(answer)
+	if err != nil {
+		return "", err
+	}
+
+	conversation.Data["due"] = b.formatDate(date)
+	return "confirm", nil
+}
+
+func (b *TelegramBot) billingWizardSummary(ctx context.Context, conversation *types.Conversation) string {
+	value, _ := strconv.ParseInt(conversation.Data["value"], 10, 64)
+
+	names := []string{}
+	for _, id := range b.wizardParticipants(conversation) {
+		name := id.String()
+		if user, err := b.service.GetUser(ctx, &types.User{UserID: id}); err == nil {
+			name = b.getUserName(user)
+		}
+		names = append(names, fmt.Sprintf("`%s`", name))
+	}
+	if len(names) == 0 {
+		names = append(names, "-")
+	}
+
+	due := conversation.Data["due"]
+	if due == "" {
+		due = "-"
+	}
+
+	return fmt.Sprintf(
+		"📝 *Create this billing?*\n\n"+
+			"💬 *Name:* `%s`\n"+
+			"💸 *Value:* %s\n"+
+			"👥 *Participants:* %s\n"+
+			"⏰ *Due Date:* %s",
+		conversation.Data["name"],
+		money.Format(value, conversation.Data["currency"]),
+		strings.Join(names, ", "),
+		due,
+	)
+}
+
+// billingWizardConfirm creates the billing with the participants in a single
+// transaction, the conversation ends in both answers
+func (b *TelegramBot) billingWizardConfirm(ctx context.Context, m *tgbotapi.Message, conversation *types.Conversation) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(m.Text)) {
+	case confirmOption.value:
+	case abortOption.value:
+		msg := tgbotapi.NewMessage(m.Chat.ID, "❌ Cancelled")
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return "", nil
+	default:
+		return "", fmt.Errorf("invalid answer, expected: yes or no")
+	}
+
+	value, err := strconv.ParseInt(conversation.Data["value"], 10, 64)
+	if err != nil {
+		return "", err
+	}
+
+	newBilling := &types.Billing{
+		Name:     conversation.Data["name"],
+		Value:    value,
+		Currency: conversation.Data["currency"],
+		ChatID:   m.Chat.ID,
+		Payments: []types.Payment{},
+	}
+	for _, id := range b.wizardParticipants(conversation) {
+		newBilling.Payments = append(newBilling.Payments, types.Payment{UserID: id})
+	}
+	if due := conversation.Data["due"]; due != "" {
+		if newBilling.DueAt, err = b.parseDate(due); err != nil {
+			return "", err
+		}
+	}
+
+	billing, err := b.service.CreateBilling(ctx, newBilling)
+	if err != nil {
+		b.logger.Error("error creating billing", zap.String("name", newBilling.Name), zap.Int64("value", newBilling.Value), zap.Error(err))
+
+		// The name may have been taken since it was checked, the wizard ends
+		// and has to be started again
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error creating billing %s: %s", newBilling.Name, err.Error()))
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return "", nil
+	}
+
+	messageText := fmt.Sprintf(
+		"🤑 *Billing Created Successfully!*\n\n"+
+			"🆔 *ID:* `%s`\n"+
+			"💬 *Name:* `%s`\n"+
+			"💸 *Value:* %s\n"+
+			"⏰ *Due Date:* %s\n",
+		billing.ID,
+		billing.Name,
+		money.Format(billing.Value, billing.Currency),
+		b.formatDate(billing.DueAt),
+	)
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+
+	b.SendBudgetAlerts(ctx)
+	return "", nil
+}
+
+func (b *TelegramBot) wizardParticipants(conversation *types.Conversation) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, value := range strings.Split(conversation.Data["participants"], ",") {
+		if id, err := uuid.Parse(value); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,214 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// budgetBarLength is the number of blocks of the budget progress bars
+const budgetBarLength = 10
+
+func (b *TelegramBot) CreateBudget(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Fields(m.CommandArguments())
+
+	if len(data) < 1 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <amount> [category=<name>] [period=<weekly|monthly|yearly>] [currency=<ISO-4217>]")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	budget, err := b.parseBudget(m, data)
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	created, err := b.service.CreateBudget(ctx, budget)
+	if err != nil {
+		b.logger.Error("error creating budget", zap.String("category", budget.Category), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error creating budget: %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := "🎯 *Budget Created Successfully!*\n\n" + b.formatBudget(created) +
+		"\nAlerts are posted when 80% and 100% of it are spent, use /budget\\_status to follow it"
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+
+	// Billings created before the budget may have reached it already
+	b.SendBudgetAlerts(ctx)
+}
+
+// parseBudget reads the /budget_add arguments, the amount is informed in the
+// currency of the budget
+func (b *TelegramBot) parseBudget(m *tgbotapi.Message, data []string) (*types.Budget, error) {
+	options, err := b.parseOptions(data[1:], "category", "period", "currency")
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := money.NormalizeCurrency(options["currency"])
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := money.Parse(data[0], currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount for budget, expected decimal, received: %s", data[0])
+	}
+
+	return &types.Budget{
+		ChatID:   m.Chat.ID,
+		Category: options["category"],
+		Amount:   amount,
+		Currency: currency,
+		Period:   types.Recurrence(options["period"]),
+	}, nil
+}
+
+func (b *TelegramBot) ListBudgets(ctx context.Context, m *tgbotapi.Message) {
+	budgets, err := b.service.ListBudgets(ctx, m.Chat.ID)
+	if err != nil {
+		b.logger.Error("failed to list budgets", zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while getting budgets")
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := fmt.Sprintf("🎯 *Budgets Found:* %d\n\n", len(budgets))
+	for _, budget := range budgets {
+		messageText += b.formatBudget(budget) + "\n"
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+func (b *TelegramBot) BudgetStatus(ctx context.Context, m *tgbotapi.Message) {
+	statuses, err := b.service.ListBudgetStatus(ctx, m.Chat.ID, time.Now())
+	if err != nil {
+		b.logger.Error("failed to get budget status", zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while getting budget status")
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := "🎯 *Budget Status*\n\n"
+	if len(statuses) == 0 {
+		messageText += "No budgets found, use /budget\\_add to create one"
+	}
+
+	for _, status := range statuses {
+		messageText += b.formatBudgetStatus(status) + "\n"
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+// SendBudgetAlerts posts to the group of every budget that reached 80% or
+// 100% of its amount in the current period, once per threshold
+func (b *TelegramBot) SendBudgetAlerts(ctx context.Context) {
+	alerts, err := b.service.ListBudgetAlerts(ctx, time.Now())
+	if err != nil {
+		b.logger.Error("failed to list budget alerts", zap.Error(err))
+		return
+	}
+
+	for _, alert := range alerts {
+		title := "⚠️ *Budget Almost Reached*"
+		if alert.Threshold >= 100 {
+			title = "🚨 *Budget Reached*"
+		}
+
+		msg := tgbotapi.NewMessage(alert.Status.Budget.ChatID, title+"\n\n"+b.formatBudgetStatus(alert.Status))
+		msg.ParseMode = tgbotapi.ModeMarkdown
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending budget alert", zap.Int64("ChatID", alert.Status.Budget.ChatID), zap.Error(err))
+			continue
+		}
+
+		if err := b.service.MarkBudgetAlerted(ctx, alert); err != nil {
+			b.logger.Error("failed to mark budget alert as sent", zap.Error(err))
+		}
+	}
+}
+
+func (b *TelegramBot) formatBudget(budget *types.Budget) string {
+	return fmt.Sprintf(
+		"🗂 *Category:* %s\n"+
+			"💸 *Amount:* %s %s\n",
+		b.formatBudgetCategory(budget),
+		money.Format(budget.Amount, budget.Currency),
+		budget.Period,
+	)
+}
+
+func (b *TelegramBot) formatBudgetStatus(status types.BudgetStatus) string {
+	budget := status.Budget
+
+	filled := min(status.Percent*budgetBarLength/100, budgetBarLength)
+	bar := strings.Repeat("🟥", filled) + strings.Repeat("⬜", budgetBarLength-filled)
+	if status.Percent < 80 {
+		bar = strings.Repeat("🟩", filled) + strings.Repeat("⬜", budgetBarLength-filled)
+	}
+
+	return fmt.Sprintf(
+		"🗂 *Category:* %s\n"+
+			"📅 *Period:* %s to %s\n"+
+			"💸 *Spent:* %s of %s (%d%%)\n"+
+			"🧾 *Billings:* %d\n"+
+			"%s\n",
+		b.formatBudgetCategory(budget),
+		status.PeriodStart.Format("2006-01-02"),
+		status.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"),
+		money.Format(status.Spent, budget.Currency),
+		money.Format(budget.Amount, budget.Currency),
+		status.Percent,
+		status.Billings,
+		bar,
+	)
+}
+
+func (b *TelegramBot) formatBudgetCategory(budget *types.Budget) string {
+	if budget.Category == "" {
+		return "all billings"
+	}
+	return fmt.Sprintf("`%s`", budget.Category)
+}
@@ -0,0 +1,115 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// commandCallback runs the command with the button args as if the user had
+// sent it, so middlewares and the audit log apply to buttons too
+func (b *TelegramBot) commandCallback(command string) CallbackHandler {
+	return func(ctx context.Context, query *tgbotapi.CallbackQuery, args []string) {
+		b.answerCallback(query, "")
+		if query.Message == nil {
+			return
+		}
+
+		text := strings.TrimSpace("/" + command + " " + strings.Join(args, " "))
+		b.Handle(&tgbotapi.Message{
+			MessageID: query.Message.MessageID,
+			From:      query.From,
+			Date:      query.Message.Date,
+			Chat:      query.Message.Chat,
+			Text:      text,
+			Entities:  []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command) + 1}},
+		})
+	}
+}
+
+// joinBillingCallback associates the member who pressed the button to the
+// billing with the default share, admins change shares with /payment_associate
+func (b *TelegramBot) joinBillingCallback(ctx context.Context, query *tgbotapi.CallbackQuery, args []string) {
+	if len(args) != 1 || query.Message == nil {
+		b.answerCallback(query, "⚠️ Invalid billing")
+		return
+	}
+
+	billingID, err := uuid.Parse(args[0])
+	if err != nil {
+		b.answerCallback(query, "⚠️ Invalid billing")
+		return
+	}
+
+	user, err := b.service.GetUser(ctx, &types.User{TelegramID: query.From.ID})
+	if err == sql.ErrNoRows {
+		b.answerCallback(query, "⚠️ User not found, use /user_add first")
+		return
+	}
+	if err != nil {
+		b.logger.Error("failed to get user", zap.Int64("TelegramID", query.From.ID), zap.Error(err))
+		b.answerCallback(query, "⚠️ Internal error while getting user")
+		return
+	}
+
+	billing, err := b.service.JoinBilling(ctx, &types.Billing{ID: billingID, ChatID: query.Message.Chat.ID}, user)
+	if err == sql.ErrNoRows {
+		b.answerCallback(query, "⚠️ Billing not found")
+		return
+	}
+	if err != nil {
+		b.logger.Error("failed to join billing", zap.String("ID", args[0]), zap.Error(err))
+		b.answerCallback(query, fmt.Sprintf("⚠️ Error while joining billing: %s", err.Error()))
+		return
+	}
+
+	b.answerCallback(query, fmt.Sprintf("🙋 %s joined %s", b.getUserName(user), billing.Name))
+}
+
+// billingPageCallback edits the /billing_list message to show another page,
+// the filters are read again from the command the list replied to
+func (b *TelegramBot) billingPageCallback(ctx context.Context, query *tgbotapi.CallbackQuery, args []string) {
+	if len(args) != 1 || query.Message == nil {
+		b.answerCallback(query, "⚠️ Invalid page")
+		return
+	}
+
+	page, err := strconv.Atoi(args[0])
+	if err != nil {
+		b.answerCallback(query, "⚠️ Invalid page")
+		return
+	}
+
+	filter := types.BillingFilter{ChatID: query.Message.Chat.ID}
+	if command := query.Message.ReplyToMessage; command != nil && command.IsCommand() {
+		filter, err = b.parseBillingFilter(ctx, command)
+		if err != nil {
+			b.answerCallback(query, fmt.Sprintf("⚠️ %s", err.Error()))
+			return
+		}
+	}
+
+	billings, err := b.service.ListBillings(ctx, filter)
+	if err != nil {
+		b.logger.Error("failed to list billings", zap.Error(err))
+		b.answerCallback(query, "⚠️ Internal error while getting billings")
+		return
+	}
+	b.answerCallback(query, "")
+
+	messageText, keyboard := b.formatBillingPage(billings, page)
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, messageText)
+	edit.ParseMode = tgbotapi.ModeMarkdown
+	edit.ReplyMarkup = keyboard
+	if _, err := b.Bot.Send(edit); err != nil {
+		b.logger.Error("error while editing message", zap.Error(err))
+	}
+}
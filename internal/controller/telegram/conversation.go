@@ -0,0 +1,282 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"misaki/internal/service"
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// defaultConversationTimeout cancels the flows without answer when the config
+// does not set a timeout
+const defaultConversationTimeout = 10 * time.Minute
+
+// conversationKey identifies a conversation, users answer one flow per chat
+type conversationKey struct {
+	chatID     int64
+	telegramID int64
+}
+
+// conversationFlow is a state machine of questions, the conversation starts
+// at the first step and each answer moves it to the step its handler returns
+type conversationFlow struct {
+	first string
+	steps map[string]conversationStep
+}
+
+// conversationStep asks a question, handle validates the answer, keeps it in
+// the conversation data and returns the next step, empty when the flow ended.
+// Answers that return an error ask the question again
+type conversationStep struct {
+	prompt  func(context.Context, *types.Conversation) string
+	options []conversationOption
+	handle  func(context.Context, *tgbotapi.Message, *types.Conversation) (string, error)
+}
+
+// conversationOption is an answer offered as a button
+type conversationOption struct {
+	label string
+	value string
+}
+
+// LoadConversations restores the conversations saved before a restart,
+// nothing is loaded when persistence is disabled
+func (b *TelegramBot) LoadConversations(ctx context.Context) error {
+	if !b.config.Conversations.Persist {
+		return nil
+	}
+
+	conversations, err := b.service.ListConversations(ctx)
+	if err != nil {
+		return err
+	}
+
+	b.conversationsMu.Lock()
+	defer b.conversationsMu.Unlock()
+
+	for _, conversation := range conversations {
+		key := conversationKey{conversation.ChatID, conversation.TelegramID}
+		b.conversations[key] = conversation
+	}
+
+	b.logger.Info("Conversations loaded", zap.Int("conversations", len(conversations)))
+	return nil
+}
+
+// ExpireConversations cancels the conversations without answer for longer than
+// the timeout and warns the users
+func (b *TelegramBot) ExpireConversations(ctx context.Context) {
+	b.conversationsMu.Lock()
+	expired := []*types.Conversation{}
+	for key, conversation := range b.conversations {
+		if b.conversationExpired(conversation) {
+			expired = append(expired, conversation)
+			delete(b.conversations, key)
+		}
+	}
+	b.conversationsMu.Unlock()
+
+	for _, conversation := range expired {
+		b.deleteConversation(ctx, conversation)
+
+		msg := tgbotapi.NewMessage(conversation.ChatID, fmt.Sprintf("⌛ The /%s wizard was cancelled after %s without answer",
+			strings.ReplaceAll(conversation.Flow, "_", "\\_"),
+			b.conversationTimeout(),
+		))
+		msg.ParseMode = tgbotapi.ModeMarkdown
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+	}
+}
+
+// Cancel ends the conversation of the user in the chat
+func (b *TelegramBot) Cancel(ctx context.Context, m *tgbotapi.Message) {
+	conversation := b.getConversation(m.Chat.ID, m.From.ID)
+
+	messageText := "⚠️ Nothing to cancel"
+	if conversation != nil {
+		b.endConversation(ctx, conversation)
+		messageText = "❌ Cancelled"
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ReplyToMessageID = m.MessageID
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+// startConversation starts the flow for the user, replacing the conversation
+// the user had in the chat
+func (b *TelegramBot) startConversation(ctx context.Context, m *tgbotapi.Message, flow string) {
+	conversation := &types.Conversation{
+		ChatID:     m.Chat.ID,
+		TelegramID: m.From.ID,
+		Flow:       flow,
+		Step:       b.flows[flow].first,
+		Data:       map[string]string{},
+	}
+
+	b.saveConversation(ctx, conversation)
+	b.promptConversation(ctx, m, conversation)
+}
+
+// continueConversation handles the message as the answer to the conversation
+// of the user, false when the user has no conversation in the chat
+func (b *TelegramBot) continueConversation(ctx context.Context, m *tgbotapi.Message) bool {
+	if m.From == nil {
+		return false
+	}
+
+	conversation := b.getConversation(m.Chat.ID, m.From.ID)
+	if conversation == nil {
+		return false
+	}
+
+	step, ok := b.flows[conversation.Flow].steps[conversation.Step]
+	if !ok {
+		b.logger.Error("unknown conversation step", zap.String("flow", conversation.Flow), zap.String("step", conversation.Step))
+		b.endConversation(ctx, conversation)
+		return false
+	}
+
+	ctx = service.WithAuditActor(ctx, types.AuditActor{
+		ChatID:     m.Chat.ID,
+		TelegramID: m.From.ID,
+		Command:    conversation.Flow,
+		Arguments:  conversation.Step,
+	})
+
+	next, err := step.handle(ctx, m, conversation)
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+
+		b.promptConversation(ctx, m, conversation)
+		return true
+	}
+
+	if next == "" {
+		b.endConversation(ctx, conversation)
+		return true
+	}
+
+	conversation.Step = next
+	b.saveConversation(ctx, conversation)
+	b.promptConversation(ctx, m, conversation)
+	return true
+}
+
+// answerConversationCallback answers the conversation of the user who pressed
+// an option button with the option value
+func (b *TelegramBot) answerConversationCallback(ctx context.Context, query *tgbotapi.CallbackQuery, args []string) {
+	if len(args) != 1 || query.Message == nil {
+		b.answerCallback(query, "⚠️ Invalid answer")
+		return
+	}
+
+	if b.getConversation(query.Message.Chat.ID, query.From.ID) == nil {
+		b.answerCallback(query, "⚠️ You have no wizard running here")
+		return
+	}
+	b.answerCallback(query, "")
+
+	b.continueConversation(ctx, &tgbotapi.Message{
+		MessageID: query.Message.MessageID,
+		From:      query.From,
+		Date:      query.Message.Date,
+		Chat:      query.Message.Chat,
+		Text:      args[0],
+	})
+}
+
+// promptConversation asks the question of the current step, the question
+// forces a reply so the answer reaches the bot in groups
+func (b *TelegramBot) promptConversation(ctx context.Context, m *tgbotapi.Message, conversation *types.Conversation) {
+	step := b.flows[conversation.Flow].steps[conversation.Step]
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, step.prompt(ctx, conversation)+"\n\nUse /cancel to stop")
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+
+	if len(step.options) > 0 {
+		row := []tgbotapi.InlineKeyboardButton{}
+		for _, option := range step.options {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(option.label, callbackData("answer", option.value)))
+		}
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
+	}
+
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+// getConversation returns the conversation of the user in the chat, nil when
+// there is none or it expired
+func (b *TelegramBot) getConversation(chatID, telegramID int64) *types.Conversation {
+	b.conversationsMu.Lock()
+	defer b.conversationsMu.Unlock()
+
+	conversation, ok := b.conversations[conversationKey{chatID, telegramID}]
+	if !ok || b.conversationExpired(conversation) {
+		return nil
+	}
+	return conversation
+}
+
+func (b *TelegramBot) saveConversation(ctx context.Context, conversation *types.Conversation) {
+	// ExpireConversations reads UpdatedAt with the lock held
+	b.conversationsMu.Lock()
+	conversation.UpdatedAt = time.Now()
+	b.conversations[conversationKey{conversation.ChatID, conversation.TelegramID}] = conversation
+	b.conversationsMu.Unlock()
+
+	if !b.config.Conversations.Persist {
+		return
+	}
+
+	if err := b.service.SaveConversation(ctx, conversation); err != nil {
+		b.logger.Error("failed to save conversation", zap.String("flow", conversation.Flow), zap.Error(err))
+	}
+}
+
+func (b *TelegramBot) endConversation(ctx context.Context, conversation *types.Conversation) {
+	b.conversationsMu.Lock()
+	delete(b.conversations, conversationKey{conversation.ChatID, conversation.TelegramID})
+	b.conversationsMu.Unlock()
+
+	b.deleteConversation(ctx, conversation)
+}
+
+func (b *TelegramBot) deleteConversation(ctx context.Context, conversation *types.Conversation) {
+	if !b.config.Conversations.Persist {
+		return
+	}
+
+	if err := b.service.DeleteConversation(ctx, conversation); err != nil {
+		b.logger.Error("failed to delete conversation", zap.String("flow", conversation.Flow), zap.Error(err))
+	}
+}
+
+func (b *TelegramBot) conversationExpired(conversation *types.Conversation) bool {
+	return time.Since(conversation.UpdatedAt) > b.conversationTimeout()
+}
+
+func (b *TelegramBot) conversationTimeout() time.Duration {
+	if b.config.Conversations.Timeout > 0 {
+		return b.config.Conversations.Timeout
+	}
+	return defaultConversationTimeout
+}
@@ -0,0 +1,84 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"misaki/internal/export"
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func (b *TelegramBot) ExportBillings(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Fields(m.CommandArguments())
+
+	if len(data) > 2 {
+		b.logger.Error("invalid billing export arguments", zap.Int("number arguments", len(data)))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: [billing-identifier|all] [csv|xlsx]")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	target, rawFormat := "all", ""
+	if len(data) > 0 {
+		target = data[0]
+	}
+	if len(data) > 1 {
+		rawFormat = data[1]
+	}
+
+	format, err := export.ParseFormat(rawFormat)
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	var billing *types.Billing
+	if target != "all" {
+		billing, err = b.parseBillingIdentifier(m.Chat.ID, target)
+		if err != nil {
+			msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get billing: %s", err.Error()))
+			msg.ReplyToMessageID = m.MessageID
+			if _, err := b.Bot.Send(msg); err != nil {
+				b.logger.Error("error while sending message", zap.Error(err))
+			}
+			return
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := b.service.ExportBillings(ctx, buffer, m.Chat.ID, billing, format); err != nil {
+		b.logger.Error("failed to export billings", zap.String("billing", target), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while exporting billings")
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Billing %s not found", target))
+		}
+
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	filename := fmt.Sprintf("billings-%s-%s.%s", target, time.Now().Format("2006-01-02"), format)
+	document := tgbotapi.NewDocument(m.Chat.ID, tgbotapi.FileBytes{Name: filename, Bytes: buffer.Bytes()})
+	document.ReplyToMessageID = m.MessageID
+	if _, err := b.Bot.Send(document); err != nil {
+		b.logger.Error("error while sending document", zap.Error(err))
+	}
+}
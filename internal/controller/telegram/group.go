@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// chatGroup returns the group of the chat the message was sent in, private
+// chats are named after the user
+func (b *TelegramBot) chatGroup(m *tgbotapi.Message) *types.Group {
+	title := m.Chat.Title
+	if title == "" {
+		title = strings.TrimSpace(fmt.Sprintf("%s %s", m.Chat.FirstName, m.Chat.LastName))
+	}
+
+	return &types.Group{
+		ChatID: m.Chat.ID,
+		Title:  title,
+	}
+}
+
+// ClaimLegacyBillings moves the billings created before groups existed to the
+// group of the chat
+func (b *TelegramBot) ClaimLegacyBillings(ctx context.Context, m *tgbotapi.Message) {
+	group := b.chatGroup(m)
+
+	claimed, err := b.service.ClaimLegacyBillings(ctx, group)
+	if err != nil {
+		b.logger.Error("failed to claim legacy billings", zap.Int64("ChatID", m.Chat.ID), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error while claiming billings: %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	b.logger.Info("Legacy billings claimed", zap.Int64("ChatID", m.Chat.ID), zap.Int64("billings", claimed))
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("📦 *Billings Claimed:* %d billings moved to `%s`", claimed, group.Title))
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
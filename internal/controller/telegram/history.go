@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func (b *TelegramBot) BillingHistory(ctx context.Context, m *tgbotapi.Message) {
+	id := m.CommandArguments()
+
+	billing, err := b.parseBillingIdentifier(m.Chat.ID, id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get billing: %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	billing, changes, err := b.service.ListBillingHistory(ctx, billing)
+	if err != nil {
+		b.logger.Error("failed to list billing history", zap.String("ID", id), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Internal error while getting billing history: %s", id))
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Billing %s not found", id))
+		}
+
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := fmt.Sprintf("📜 *Billing History:* `%s`\n\n", billing.Name)
+	if len(changes) == 0 {
+		messageText += "No changes made to this billing"
+	}
+
+	for _, change := range changes {
+		messageText += fmt.Sprintf("📅 %s by `%s`\n✏️ *%s:* %s ➡️ %s\n\n",
+			change.CreatedAt.Format("2006-01-02 15:04:05"),
+			b.formatActor(change),
+			change.Field,
+			b.formatChangeValue(change.OldValue),
+			b.formatChangeValue(change.NewValue),
+		)
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+func (b *TelegramBot) formatActor(change types.BillingChange) string {
+	if change.Actor == nil {
+		return "unknown"
+	}
+	return b.getUserName(change.Actor)
+}
+
+func (b *TelegramBot) formatChangeValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return fmt.Sprintf("`%s`", value)
+}
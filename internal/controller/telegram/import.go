@@ -0,0 +1,251 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/internal/service"
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// importTimeout is how long a preview waits for the admin confirmation
+	importTimeout = time.Hour
+
+	maxImportSize = 1 << 20
+)
+
+// downloadClient fetches the files sent to the bot, a stalled download must
+// not hold the update forever
+var downloadClient = &http.Client{Timeout: 30 * time.Second}
+
+// pendingImport is a previewed import waiting for confirmation
+type pendingImport struct {
+	rows       []types.BillingImportRow
+	telegramID int64
+	chatID     int64
+	createdAt  time.Time
+}
+
+func (b *TelegramBot) ImportBillings(ctx context.Context, m *tgbotapi.Message) {
+	if m.Document == nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID,
+			"📥 Reply to this message with a CSV file with the columns: name, value, due date (YYYY-MM-DD), user identifiers separated by comma and an optional currency\n\n"+
+				"/billing_import",
+		)
+		msg.ReplyToMessageID = m.MessageID
+		msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	if m.Document.FileSize > maxImportSize {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ File too large, maximum size is %d KB", maxImportSize>>10))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	content, err := b.downloadFile(m.Document.FileID)
+	if err != nil {
+		b.logger.Error("failed to download import file", zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while downloading file")
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	rows, err := service.ParseBillingImport(bytes.NewReader(content))
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	rows, _, err = b.service.ImportBillings(ctx, m.Chat.ID, rows, true)
+	if err != nil {
+		b.logger.Error("failed to preview import", zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while validating import")
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	valid := true
+	for _, row := range rows {
+		if row.Error != "" {
+			valid = false
+		}
+	}
+
+	messageText := "📥 *Import Preview*\n\n" + b.formatImportRows(rows)
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+
+	if valid {
+		id := b.addPendingImport(rows, m.From.ID, m.Chat.ID)
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ Confirm", callbackData("import_confirm", id.String())),
+				tgbotapi.NewInlineKeyboardButtonData("❌ Cancel", callbackData("import_cancel", id.String())),
+			),
+		)
+	} else {
+		msg.Text += "\n⚠️ Fix the errors and send the file again"
+	}
+
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+// confirmImport imports or discards a previewed file, only the admin who sent
+// it can answer
+func (b *TelegramBot) confirmImportCallback(ctx context.Context, query *tgbotapi.CallbackQuery, args []string) {
+	b.confirmImport(ctx, query, strings.Join(args, ""), true)
+}
+
+func (b *TelegramBot) cancelImportCallback(ctx context.Context, query *tgbotapi.CallbackQuery, args []string) {
+	b.confirmImport(ctx, query, strings.Join(args, ""), false)
+}
+
+func (b *TelegramBot) confirmImport(ctx context.Context, query *tgbotapi.CallbackQuery, id string, confirm bool) {
+	importID, err := uuid.Parse(id)
+	if err != nil {
+		b.answerCallback(query, "⚠️ Invalid import")
+		return
+	}
+
+	pending := b.takePendingImport(importID, query.From.ID)
+	if pending == nil {
+		b.answerCallback(query, "⚠️ Import expired or not found")
+		return
+	}
+
+	result := "❌ Import cancelled"
+	if confirm {
+		rows, imported, err := b.service.ImportBillings(ctx, pending.chatID, pending.rows, false)
+		switch {
+		case err != nil:
+			b.logger.Error("failed to import billings", zap.Error(err))
+			result = fmt.Sprintf("⚠️ Error while importing billings: %s", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, err.Error()))
+		case !imported:
+			result = "⚠️ Import not applied, the billings changed since the preview:\n\n" + b.formatImportRows(rows)
+		default:
+			result = fmt.Sprintf("✅ %d billings imported", len(rows))
+			b.SendBudgetAlerts(ctx)
+		}
+	}
+
+	b.answerCallback(query, "")
+	if query.Message == nil {
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, result)
+	edit.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(edit); err != nil {
+		b.logger.Error("error while editing message", zap.Error(err))
+	}
+}
+
+func (b *TelegramBot) addPendingImport(rows []types.BillingImportRow, telegramID, chatID int64) uuid.UUID {
+	b.importsMu.Lock()
+	defer b.importsMu.Unlock()
+
+	// Drop previews never answered
+	for id, pending := range b.imports {
+		if time.Since(pending.createdAt) > importTimeout {
+			delete(b.imports, id)
+		}
+	}
+
+	id := uuid.New()
+	b.imports[id] = &pendingImport{
+		rows:       rows,
+		telegramID: telegramID,
+		chatID:     chatID,
+		createdAt:  time.Now(),
+	}
+	return id
+}
+
+func (b *TelegramBot) takePendingImport(id uuid.UUID, telegramID int64) *pendingImport {
+	b.importsMu.Lock()
+	defer b.importsMu.Unlock()
+
+	pending, ok := b.imports[id]
+	if !ok || pending.telegramID != telegramID || time.Since(pending.createdAt) > importTimeout {
+		return nil
+	}
+
+	delete(b.imports, id)
+	return pending
+}
+
+func (b *TelegramBot) formatImportRows(rows []types.BillingImportRow) string {
+	text := ""
+	for _, row := range rows {
+		if row.Error != "" {
+			text += fmt.Sprintf("❌ Line %d: %s\n", row.Line, tgbotapi.EscapeText(tgbotapi.ModeMarkdown, row.Error))
+			continue
+		}
+
+		billing := row.Billing
+		users := make([]string, 0, len(billing.Payments))
+		for _, payment := range billing.Payments {
+			users = append(users, b.getUserName(&payment.UserInfo))
+		}
+
+		text += fmt.Sprintf("✅ Line %d: `%s` %s, due %s, users: %s\n",
+			row.Line,
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdown, billing.Name),
+			money.Format(billing.Value, billing.Currency),
+			b.formatDate(billing.DueAt),
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdown, strings.Join(users, ", ")),
+		)
+	}
+	return text
+}
+
+// downloadFile fetches the content of a file sent to the bot
+func (b *TelegramBot) downloadFile(fileID string) ([]byte, error) {
+	url, err := b.Bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := downloadClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status downloading file: %s", resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxImportSize))
+}
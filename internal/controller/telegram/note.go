@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// AddBillingNote leaves a note on the billing, photos and documents sent with
+// the command as caption are attached to the note
+func (b *TelegramBot) AddBillingNote(ctx context.Context, m *tgbotapi.Message) {
+	id, text, _ := strings.Cut(strings.TrimSpace(m.CommandArguments()), " ")
+
+	billing, err := b.parseBillingIdentifier(m.Chat.ID, id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get billing: %s, expected: <billing-identifier> <text>", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	author, err := b.service.GetUser(ctx, &types.User{TelegramID: m.From.ID})
+	if err != nil {
+		b.logger.Error("failed to get user", zap.Int64("TelegramID", m.From.ID), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while getting user")
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, "⚠️ User not found, use /user_add first")
+		}
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	note := &types.BillingNote{
+		AuthorID: author.UserID,
+		Text:     text,
+	}
+	note.FileID, note.FileType = b.parseNoteFile(m)
+
+	note, err = b.service.AddBillingNote(ctx, billing, note)
+	if err != nil {
+		b.logger.Error("failed to add billing note", zap.String("ID", id), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error while adding note to billing %s: %s", id, err.Error()))
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Billing %s not found", id))
+		}
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("📝 *Note Added* to `%s`", id))
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+// parseNoteFile returns the photo or document sent with the message, photos
+// use the largest size sent
+func (b *TelegramBot) parseNoteFile(m *tgbotapi.Message) (string, types.ProofFileType) {
+	if len(m.Photo) > 0 {
+		return m.Photo[len(m.Photo)-1].FileID, types.ProofPhoto
+	}
+
+	if m.Document != nil {
+		return m.Document.FileID, types.ProofDocument
+	}
+
+	return "", ""
+}
+
+func (b *TelegramBot) formatNotes(billing *types.Billing) string {
+	text := "📝 *Latest Notes:*\n"
+	for _, note := range billing.Notes {
+		author := "unknown"
+		if note.Author != nil {
+			author = b.getUserName(note.Author)
+		}
+
+		attachment := ""
+		if note.FileID != "" {
+			attachment = " 📎"
+		}
+
+		text += fmt.Sprintf("  📅 %s `%s`: %s%s\n",
+			note.CreatedAt.Format("2006-01-02 15:04"),
+			author,
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdown, note.Text),
+			attachment,
+		)
+	}
+
+	return text
+}
+
+// sendNoteFiles sends the files attached to the latest notes of the billing
+func (b *TelegramBot) sendNoteFiles(m *tgbotapi.Message, billing *types.Billing) {
+	for _, note := range billing.Notes {
+		if note.FileID == "" {
+			continue
+		}
+
+		caption := fmt.Sprintf("📎 %s note %s", billing.Name, note.CreatedAt.Format("2006-01-02 15:04"))
+
+		var file tgbotapi.Chattable
+		if note.FileType == types.ProofPhoto {
+			photo := tgbotapi.NewPhoto(m.Chat.ID, tgbotapi.FileID(note.FileID))
+			photo.Caption = caption
+			file = photo
+		} else {
+			document := tgbotapi.NewDocument(m.Chat.ID, tgbotapi.FileID(note.FileID))
+			document.Caption = caption
+			file = document
+		}
+
+		if _, err := b.Bot.Send(file); err != nil {
+			b.logger.Error("error while sending note file", zap.Error(err))
+		}
+	}
+}
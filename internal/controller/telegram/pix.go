@@ -0,0 +1,115 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/skip2/go-qrcode"
+	"go.uber.org/zap"
+)
+
+const pixQRCodeSize = 512
+
+func (b *TelegramBot) SetPixKey(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Fields(m.CommandArguments())
+
+	if len(data) == 0 || (len(data) < 2 && data[0] != "none") {
+		b.logger.Error("invalid pix key arguments", zap.Int("number arguments", len(data)))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <pix-key|none> <city>")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	key := data[0]
+	if key == "none" {
+		key = ""
+	}
+	city := strings.Join(data[1:], " ")
+
+	user := &types.User{
+		TelegramID: m.From.ID,
+	}
+	if err := b.service.SetUserPix(ctx, user, key, city); err != nil {
+		b.logger.Error("failed to change pix key", zap.Int64("TelegramID", m.From.ID), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error while changing pix key: %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := "🔑 *PIX key removed*"
+	if key != "" {
+		messageText = fmt.Sprintf(
+			"🔑 *PIX key registered*\n\n"+
+				"🔑 *Key:* `%s`\n"+
+				"🏙️ *City:* `%s`\n",
+			user.PixKey,
+			user.PixCity,
+		)
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+// sendPixCharge sends the PIX "copia e cola" payload and its QR code for the
+// amount the user who sent the message still owes in the billing, nothing is
+// sent when there is no charge for the user
+func (b *TelegramBot) sendPixCharge(ctx context.Context, m *tgbotapi.Message, billing *types.Billing) {
+	user, err := b.service.GetUser(ctx, &types.User{TelegramID: m.From.ID})
+	if err != nil {
+		return
+	}
+
+	charge, err := b.service.GetPixCharge(ctx, billing, user)
+	if err != nil {
+		b.logger.Debug("no pix charge for user", zap.Int64("TelegramID", m.From.ID), zap.Error(err))
+		return
+	}
+
+	png, err := qrcode.Encode(charge.Payload, qrcode.Medium, pixQRCodeSize)
+	if err != nil {
+		b.logger.Error("failed to render pix qr code", zap.Error(err))
+		return
+	}
+
+	caption := fmt.Sprintf(
+		"💠 *PIX*\n\n"+
+			"💬 *Billing:* `%s`\n"+
+			"🏦 *Receiver:* `%s`\n"+
+			"💸 *Value:* %s\n",
+		billing.Name,
+		b.getUserName(&charge.Receiver),
+		money.Format(charge.Amount, billing.Currency),
+	)
+
+	photo := tgbotapi.NewPhoto(m.Chat.ID, tgbotapi.FileBytes{Name: "pix.png", Bytes: png})
+	photo.Caption = caption
+	photo.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(photo); err != nil {
+		b.logger.Error("error while sending photo", zap.Error(err))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("📋 *PIX copia e cola:*\n`%s`", charge.Payload))
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
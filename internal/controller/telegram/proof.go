@@ -0,0 +1,342 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// parseProofFile returns the receipt attached to the message, photos use the
+// largest size sent and documents must be PDFs or images
+func (b *TelegramBot) parseProofFile(m *tgbotapi.Message) (string, types.ProofFileType, bool) {
+	if len(m.Photo) > 0 {
+		return m.Photo[len(m.Photo)-1].FileID, types.ProofPhoto, true
+	}
+
+	if m.Document != nil && (m.Document.MimeType == "application/pdf" || strings.HasPrefix(m.Document.MimeType, "image/")) {
+		return m.Document.FileID, types.ProofDocument, true
+	}
+
+	return "", "", false
+}
+
+// requestPaymentProof asks the user to reply with the receipt, the reply runs
+// the command written in the last line of the prompt
+func (b *TelegramBot) requestPaymentProof(m *tgbotapi.Message) {
+	messageText := fmt.Sprintf(
+		"🧾 Reply to this message with a photo or PDF of the payment receipt, it will be sent to the admins for review\n\n"+
+			"/billing_pay %s",
+		strings.TrimSpace(m.CommandArguments()),
+	)
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ReplyToMessageID = m.MessageID
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+func (b *TelegramBot) submitPaymentProof(ctx context.Context, m *tgbotapi.Message, billing *types.Billing, amount, note string) {
+	fileID, fileType, _ := b.parseProofFile(m)
+
+	billing, err := b.service.GetBilling(ctx, billing)
+	if err != nil {
+		b.logger.Error("failed to get billing", zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while getting billing")
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, "⚠️ Billing not found")
+		}
+
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	user, err := b.service.GetUser(ctx, &types.User{TelegramID: m.From.ID})
+	if err != nil {
+		b.logger.Error("failed to get user", zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while getting user")
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, "⚠️ User not found, use /user_add first")
+		}
+
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	proof := &types.PaymentProof{
+		BillingID: billing.ID,
+		UserID:    user.UserID,
+		Note:      note,
+		FileID:    fileID,
+		FileType:  fileType,
+	}
+
+	if amount != "" {
+		proof.Amount, err = money.Parse(amount, billing.Currency)
+		if err != nil || proof.Amount <= 0 {
+			msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Invalid amount, expected positive value, received: %s", amount))
+			msg.ReplyToMessageID = m.MessageID
+			if _, err := b.Bot.Send(msg); err != nil {
+				b.logger.Error("error while sending message", zap.Error(err))
+			}
+			return
+		}
+	}
+
+	proof, err = b.service.SubmitPaymentProof(ctx, proof)
+	if err != nil {
+		b.logger.Error("failed to submit payment proof", zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error while submitting payment proof: %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := fmt.Sprintf(
+		"🧾 *Payment Proof Received*\n\n"+
+			"💬 *Billing:* `%s`\n"+
+			"💸 *Value:* %s\n"+
+			"📌 *Status:* %s\n",
+		billing.Name,
+		money.Format(proof.Amount, billing.Currency),
+		b.formatPaymentStatus(types.PaymentPendingReview),
+	)
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+
+	b.notifyAdminsProof(ctx, proof, billing, user)
+}
+
+// notifyAdminsProof sends the receipt to every admin with buttons to approve
+// or reject it
+func (b *TelegramBot) notifyAdminsProof(ctx context.Context, proof *types.PaymentProof, billing *types.Billing, user *types.User) {
+	admins, err := b.service.ListAdmins(ctx)
+	if err != nil {
+		b.logger.Error("failed to list admins", zap.Error(err))
+		return
+	}
+
+	caption := fmt.Sprintf(
+		"🧾 *Payment Proof*\n\n"+
+			"🆔 *ID:* `%s`\n"+
+			"👤 *User:* `%s`\n"+
+			"💬 *Billing:* `%s`\n"+
+			"💸 *Value:* %s\n",
+		proof.ID,
+		b.getUserName(user),
+		billing.Name,
+		money.Format(proof.Amount, billing.Currency),
+	)
+	if proof.Note != "" {
+		caption += fmt.Sprintf("📝 *Note:* %s\n", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, proof.Note))
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Approve", callbackData("proof_approve", proof.ID.String())),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Reject", callbackData("proof_reject", proof.ID.String())),
+		),
+	)
+
+	for _, admin := range admins {
+		// Private chats share the user Telegram ID
+		var msg tgbotapi.Chattable
+		if proof.FileType == types.ProofPhoto {
+			photo := tgbotapi.NewPhoto(admin.TelegramID, tgbotapi.FileID(proof.FileID))
+			photo.Caption = caption
+			photo.ParseMode = tgbotapi.ModeMarkdown
+			photo.ReplyMarkup = keyboard
+			msg = photo
+		} else {
+			document := tgbotapi.NewDocument(admin.TelegramID, tgbotapi.FileID(proof.FileID))
+			document.Caption = caption
+			document.ParseMode = tgbotapi.ModeMarkdown
+			document.ReplyMarkup = keyboard
+			msg = document
+		}
+
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending payment proof", zap.Int64("TelegramID", admin.TelegramID), zap.Error(err))
+		}
+	}
+}
+
+// approveProofCallback approves the proof of the button, the review buttons
+// are removed once the payment is recorded
+func (b *TelegramBot) approveProofCallback(ctx context.Context, query *tgbotapi.CallbackQuery, args []string) {
+	if len(args) != 1 {
+		b.answerCallback(query, "⚠️ Invalid payment proof")
+		return
+	}
+
+	answer := b.reviewPaymentProof(ctx, query.From.ID, args[0], true, "")
+	b.answerCallback(query, answer)
+	if query.Message != nil && strings.HasPrefix(answer, "✅") {
+		b.closeProofMessage(query.Message, answer)
+	}
+}
+
+// rejectProofCallback asks the admin for the reason, it is informed replying
+// to the prompt
+func (b *TelegramBot) rejectProofCallback(ctx context.Context, query *tgbotapi.CallbackQuery, args []string) {
+	if len(args) != 1 {
+		b.answerCallback(query, "⚠️ Invalid payment proof")
+		return
+	}
+
+	b.answerCallback(query, "")
+	if query.Message == nil {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(query.Message.Chat.ID, fmt.Sprintf(
+		"❌ Reply to this message with the reason to reject the payment proof\n\n"+
+			"/proof_reject %s",
+		args[0],
+	))
+	msg.ReplyToMessageID = query.Message.MessageID
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+// closeProofMessage removes the review buttons of the proof sent to an admin,
+// the decision is added to the caption
+func (b *TelegramBot) closeProofMessage(m *tgbotapi.Message, decision string) {
+	edit := tgbotapi.NewEditMessageCaption(m.Chat.ID, m.MessageID, m.Caption+"\n\n"+decision)
+	if _, err := b.Bot.Send(edit); err != nil {
+		b.logger.Error("error while editing message", zap.Error(err))
+	}
+}
+
+func (b *TelegramBot) ApprovePaymentProof(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Fields(m.CommandArguments())
+
+	if len(data) != 1 {
+		b.logger.Error("invalid payment proof arguments", zap.Int("number arguments", len(data)))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <proof-id>")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, b.reviewPaymentProof(ctx, m.From.ID, data[0], true, ""))
+	msg.ReplyToMessageID = m.MessageID
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+func (b *TelegramBot) RejectPaymentProof(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Fields(m.CommandArguments())
+
+	if len(data) < 2 {
+		b.logger.Error("invalid payment proof arguments", zap.Int("number arguments", len(data)))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <proof-id> <reason>")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, b.reviewPaymentProof(ctx, m.From.ID, data[0], false, strings.Join(data[1:], " ")))
+	msg.ReplyToMessageID = m.MessageID
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+// reviewPaymentProof applies the admin decision, notifies the user who sent
+// the proof and returns the text describing the result
+func (b *TelegramBot) reviewPaymentProof(ctx context.Context, telegramID int64, id string, approve bool, reason string) string {
+	proofID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Sprintf("⚠️ Invalid payment proof id informed: %s", id)
+	}
+
+	reviewer, err := b.service.GetUser(ctx, &types.User{TelegramID: telegramID})
+	if err != nil {
+		b.logger.Error("failed to get user", zap.Int64("TelegramID", telegramID), zap.Error(err))
+		return "⚠️ Error validating user permission"
+	}
+	if !reviewer.Admin {
+		return "⚠️ User don't have required permission"
+	}
+
+	proof := &types.PaymentProof{ID: proofID}
+	if approve {
+		proof, _, err = b.service.ApprovePaymentProof(ctx, proof, reviewer)
+	} else {
+		proof, err = b.service.RejectPaymentProof(ctx, proof, reviewer, reason)
+	}
+	if err != nil {
+		b.logger.Error("failed to review payment proof", zap.String("ProofID", id), zap.Error(err))
+		if err == sql.ErrNoRows {
+			return fmt.Sprintf("⚠️ Payment proof %s not found", id)
+		}
+		return fmt.Sprintf("⚠️ Error while reviewing payment proof: %s", err.Error())
+	}
+
+	billing, err := b.service.GetBilling(ctx, &types.Billing{ID: proof.BillingID})
+	if err != nil {
+		b.logger.Error("failed to get billing", zap.Error(err))
+		return "⚠️ Internal error while getting billing"
+	}
+
+	decision := fmt.Sprintf("✅ Approved by %s", b.getUserName(reviewer))
+	userText := fmt.Sprintf("✅ Your payment proof of %s for billing %s was approved",
+		money.Format(proof.Amount, billing.Currency),
+		billing.Name,
+	)
+	if !approve {
+		decision = fmt.Sprintf("❌ Rejected by %s: %s", b.getUserName(reviewer), proof.Reason)
+		userText = fmt.Sprintf("❌ Your payment proof of %s for billing %s was rejected: %s",
+			money.Format(proof.Amount, billing.Currency),
+			billing.Name,
+			proof.Reason,
+		)
+	}
+
+	for _, payment := range billing.Payments {
+		if payment.UserID != proof.UserID {
+			continue
+		}
+
+		// Private chats share the user Telegram ID
+		msg := tgbotapi.NewMessage(payment.UserInfo.TelegramID, userText)
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Int64("TelegramID", payment.UserInfo.TelegramID), zap.Error(err))
+		}
+	}
+
+	return decision
+}
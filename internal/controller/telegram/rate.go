@@ -0,0 +1,205 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// maxRatesListed limits the history sent by /rate_list
+const maxRatesListed = 20
+
+func (b *TelegramBot) SetExchangeRate(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Fields(m.CommandArguments())
+
+	if len(data) < 3 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <base> <quote> <rate> [date=<YYYY-MM-DD>]")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	rate, err := b.parseExchangeRate(data)
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	rate, err = b.service.SetExchangeRate(ctx, rate)
+	if err != nil {
+		b.logger.Error("error setting exchange rate", zap.Strings("args", data), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error setting exchange rate: %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, "💱 *Exchange Rate Set*\n\n"+b.formatExchangeRate(rate))
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+// parseExchangeRate reads the /rate_set arguments, rates accept both decimal
+// separators like values
+func (b *TelegramBot) parseExchangeRate(data []string) (*types.ExchangeRate, error) {
+	options, err := b.parseOptions(data[3:], "date")
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(data[2], ",", "."), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exchange rate, expected decimal, received: %s", data[2])
+	}
+
+	rate := &types.ExchangeRate{
+		Base:  data[0],
+		Quote: data[1],
+		Rate:  value,
+	}
+
+	if date, ok := options["date"]; ok {
+		rate.EffectiveAt, err = b.parseDate(date)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rate, nil
+}
+
+func (b *TelegramBot) ListExchangeRates(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Fields(m.CommandArguments())
+
+	if len(data) > 2 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: [base] [quote]")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	var base, quote string
+	if len(data) > 0 {
+		base = data[0]
+	}
+	if len(data) > 1 {
+		quote = data[1]
+	}
+
+	rates, err := b.service.ListExchangeRates(ctx, base, quote)
+	if err != nil {
+		b.logger.Error("failed to list exchange rates", zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error while getting exchange rates: %s", err.Error()))
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	currency, err := b.service.GroupCurrency(ctx, m.Chat.ID)
+	if err != nil {
+		b.logger.Error("failed to get group currency", zap.Int64("ChatID", m.Chat.ID), zap.Error(err))
+		currency = money.DefaultCurrency
+	}
+
+	messageText := fmt.Sprintf("💱 *Exchange Rates Found:* %d\n🏦 *Settlement Currency:* %s\n\n", len(rates), currency)
+	for i, rate := range rates {
+		if i == maxRatesListed {
+			messageText += fmt.Sprintf("… and %d older rates\n", len(rates)-maxRatesListed)
+			break
+		}
+		messageText += b.formatExchangeRate(&rate)
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+// SetGroupCurrency changes the settlement currency of the group of the chat
+func (b *TelegramBot) SetGroupCurrency(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Fields(m.CommandArguments())
+
+	if len(data) != 1 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <currency>")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	group := b.chatGroup(m)
+	group.Currency = data[0]
+
+	if err := b.service.SetGroupCurrency(ctx, group); err != nil {
+		b.logger.Error("failed to set group currency", zap.Int64("ChatID", m.Chat.ID), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error setting group currency: %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("🏦 *Settlement Currency:* %s\n\nBalances and reports are converted to it, use /rate\\_set to add exchange rates", group.Currency))
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+func (b *TelegramBot) formatExchangeRate(rate *types.ExchangeRate) string {
+	return fmt.Sprintf("📅 %s: 1 %s = %s %s\n",
+		rate.EffectiveAt.Format("2006-01-02"),
+		rate.Base,
+		b.formatRate(rate.Rate),
+		rate.Quote,
+	)
+}
+
+// formatConversion shows the billing value in the settlement currency of the
+// group, empty when it is not converted
+func (b *TelegramBot) formatConversion(billing *types.Billing) string {
+	if billing.Conversion == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("💱 *Converted:* %s (1 %s = %s %s)\n",
+		money.Format(billing.Conversion.Value, billing.Conversion.Currency),
+		billing.Currency,
+		b.formatRate(billing.Conversion.Rate),
+		billing.Conversion.Currency,
+	)
+}
+
+// formatRate keeps six significant digits, inverted rates are not exact
+func (b *TelegramBot) formatRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'g', 6, 64)
+}
@@ -0,0 +1,139 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const defaultReminderCadence = 24 * time.Hour
+
+// SendReminders sends a private message to every user with an unpaid billing
+// inside the reminder window configured
+func (b *TelegramBot) SendReminders(ctx context.Context) {
+	config := b.config.Reminders
+	cadence := config.Cadence
+	if cadence <= 0 {
+		cadence = defaultReminderCadence
+	}
+
+	now := time.Now()
+	reminders, err := b.service.ListPendingReminders(ctx, now, config.DaysBefore, config.DaysAfter, cadence)
+	if err != nil {
+		b.logger.Error("failed to list pending reminders", zap.Error(err))
+		return
+	}
+
+	for _, reminder := range reminders {
+		status := "is due"
+		if now.After(reminder.Billing.DueAt) {
+			status = "is overdue"
+		}
+
+		// Billings are not found in private chats, the command has to be
+		// sent in the group of the billing
+		group := "the group chat"
+		if reminder.Group != nil && reminder.Group.Title != "" {
+			group = fmt.Sprintf("`%s`", reminder.Group.Title)
+		}
+
+		messageText := fmt.Sprintf(
+			"⏰ *Payment Reminder*\n\n"+
+				"💬 *Billing:* `%s` %s\n"+
+				"📅 *Due Date:* %s\n"+
+				"💸 *Value:* %s\n\n"+
+				"Use /billing\\_pay `%s` in %s after paying it or /reminders off to stop these messages",
+			reminder.Billing.Name,
+			status,
+			b.formatDate(reminder.Billing.DueAt),
+			money.Format(reminder.Payment.AmountDue, reminder.Billing.Currency),
+			reminder.Billing.Name,
+			group,
+		)
+
+		// Private chats share the user Telegram ID
+		msg := tgbotapi.NewMessage(reminder.Payment.UserInfo.TelegramID, messageText)
+		msg.ParseMode = tgbotapi.ModeMarkdown
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending reminder", zap.Int64("TelegramID", reminder.Payment.UserInfo.TelegramID), zap.Error(err))
+			continue
+		}
+
+		if err := b.service.MarkReminded(ctx, &reminder.Payment); err != nil {
+			b.logger.Error("failed to mark reminder as sent", zap.Error(err))
+		}
+	}
+}
+
+func (b *TelegramBot) SetReminders(ctx context.Context, m *tgbotapi.Message) {
+	user := &types.User{
+		TelegramID: m.From.ID,
+	}
+
+	arg := m.CommandArguments()
+	if arg == "" {
+		user, err := b.service.GetUser(ctx, user)
+		if err != nil {
+			b.logger.Error("failed to get user", zap.Int64("TelegramID", m.From.ID), zap.Error(err))
+
+			msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while getting user")
+			if err == sql.ErrNoRows {
+				msg = tgbotapi.NewMessage(m.Chat.ID, "⚠️ User not found, use /user_add first")
+			}
+
+			if _, err := b.Bot.Send(msg); err != nil {
+				b.logger.Error("error while sending message", zap.Error(err))
+			}
+			return
+		}
+
+		b.sendRemindersStatus(m, user.Reminders)
+		return
+	}
+
+	if arg != "on" && arg != "off" {
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid argument received, expected: [on|off]")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	if err := b.service.SetUserReminders(ctx, user, arg == "on"); err != nil {
+		b.logger.Error("failed to change reminders", zap.Int64("TelegramID", m.From.ID), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while changing reminders")
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, "⚠️ User not found, use /user_add first")
+		}
+
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	b.sendRemindersStatus(m, user.Reminders)
+}
+
+func (b *TelegramBot) sendRemindersStatus(m *tgbotapi.Message, enabled bool) {
+	status := "disabled"
+	if enabled {
+		status = "enabled"
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⏰ *Payment reminders:* %s", status))
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
@@ -0,0 +1,188 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"misaki/internal/chart"
+	"misaki/internal/money"
+	"misaki/internal/service"
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	reportChartWidth  = 800
+	reportChartHeight = 480
+
+	// Telegram limits the caption of photos
+	maxCaptionLength = 1024
+)
+
+func (b *TelegramBot) Report(ctx context.Context, m *tgbotapi.Message) {
+	period, err := service.ParsePeriod(strings.TrimSpace(m.CommandArguments()), time.Now())
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	if err := b.sendReport(ctx, m.Chat.ID, period); err != nil {
+		b.logger.Error("failed to send report", zap.Time("period", period), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while building report")
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+	}
+}
+
+// PostMonthlyReport sends the report of the previous month to the configured
+// chat on the first day of each month, once per process
+func (b *TelegramBot) PostMonthlyReport(ctx context.Context) {
+	now := time.Now()
+	if now.Day() != 1 {
+		return
+	}
+
+	period := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	if !b.lastReport.Before(period) {
+		return
+	}
+
+	if err := b.sendReport(ctx, b.config.Reports.ChatID, period); err != nil {
+		b.logger.Error("failed to post monthly report", zap.Time("period", period), zap.Error(err))
+		return
+	}
+	b.lastReport = period
+}
+
+// sendReport sends the chart of the period as a photo captioned with the
+// summary, the details go in a separate message when they don't fit
+func (b *TelegramBot) sendReport(ctx context.Context, chatID int64, period time.Time) error {
+	report, err := b.service.MonthlyReport(ctx, chatID, period, time.Now())
+	if err != nil {
+		return err
+	}
+
+	summary := b.formatReportSummary(report)
+	details := b.formatReportDetails(report)
+
+	if len(report.Totals) == 0 {
+		msg := tgbotapi.NewMessage(chatID, summary)
+		msg.ParseMode = tgbotapi.ModeMarkdown
+		_, err := b.Bot.Send(msg)
+		return err
+	}
+
+	image := &bytes.Buffer{}
+	if err := b.reportChart(report).Render(image); err != nil {
+		return err
+	}
+
+	caption := summary + details
+	if len([]rune(caption)) > maxCaptionLength {
+		caption = summary
+	} else {
+		details = ""
+	}
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "report.png", Bytes: image.Bytes()})
+	photo.Caption = caption
+	photo.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(photo); err != nil {
+		return err
+	}
+
+	if details != "" {
+		msg := tgbotapi.NewMessage(chatID, details)
+		msg.ParseMode = tgbotapi.ModeMarkdown
+		if _, err := b.Bot.Send(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportChart compares what each user owes and paid in the main currency of
+// the period
+func (b *TelegramBot) reportChart(report *types.Report) *chart.BarChart {
+	currency := report.Totals[0].Currency
+
+	owed := chart.Series{Name: "Owed", Color: chart.Palette[0]}
+	paid := chart.Series{Name: "Paid", Color: chart.Palette[1]}
+	labels := []string{}
+	for _, user := range report.Users {
+		if user.Currency != currency {
+			continue
+		}
+
+		labels = append(labels, b.getUserName(&user.User))
+		owed.Values = append(owed.Values, money.Float(user.Owed, currency))
+		paid.Values = append(paid.Values, money.Float(user.Paid, currency))
+	}
+
+	return &chart.BarChart{
+		Title:  fmt.Sprintf("%s - %s", report.Period.Format("2006-01"), currency),
+		Labels: labels,
+		Series: []chart.Series{owed, paid},
+		Width:  reportChartWidth,
+		Height: reportChartHeight,
+	}
+}
+
+func (b *TelegramBot) formatReportSummary(report *types.Report) string {
+	text := fmt.Sprintf(
+		"📊 *Report %s*\n\n"+
+			"🤑 *Billings:* %d\n",
+		report.Period.Format("2006-01"),
+		report.Billings,
+	)
+
+	for _, total := range report.Totals {
+		text += fmt.Sprintf("💰 *Total:* %s, 💵 paid %s, ⏳ remaining %s\n",
+			money.Format(total.Value, total.Currency),
+			money.Format(total.Paid, total.Currency),
+			money.Format(total.Outstanding, total.Currency),
+		)
+	}
+
+	return text
+}
+
+func (b *TelegramBot) formatReportDetails(report *types.Report) string {
+	text := ""
+	if len(report.Users) > 0 {
+		text += "\n👤 *Users*\n"
+	}
+	for _, user := range report.Users {
+		text += fmt.Sprintf("`%s`: paid %s of %s\n",
+			b.getUserName(&user.User),
+			money.Format(user.Paid, user.Currency),
+			money.Format(user.Owed, user.Currency),
+		)
+	}
+
+	if len(report.Overdue) > 0 {
+		text += "\n⏰ *Overdue*\n"
+	}
+	for _, overdue := range report.Overdue {
+		text += fmt.Sprintf("`%s` `%s` due %s: %s\n",
+			overdue.Billing,
+			b.getUserName(&overdue.User),
+			b.formatDate(overdue.DueAt),
+			money.Format(overdue.Outstanding, overdue.Currency),
+		)
+	}
+
+	return text
+}
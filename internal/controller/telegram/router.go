@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"context"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -38,21 +39,81 @@ func (r *CommandRouter) register(command string, handler CommandHandler, middlew
 	r.commands = append(r.commands, "/"+command)
 }
 
+// callbackVersion prefixes the data of every button, buttons of older
+// versions are answered as expired instead of running the wrong handler
+const callbackVersion = "1"
+
+// CallbackHandler defines the signature for callback query handler functions,
+// args are the values encoded in the button after the action.
+type CallbackHandler func(context.Context, *tgbotapi.CallbackQuery, []string)
+
+// CallbackRouter maps the actions of inline buttons to handlers.
+type CallbackRouter struct {
+	handlers map[string]CallbackHandler
+}
+
+// NewCallbackRouter creates a new CallbackRouter.
+func NewCallbackRouter() *CallbackRouter {
+	return &CallbackRouter{handlers: make(map[string]CallbackHandler)}
+}
+
+// register adds an action and its handler to the router.
+func (r *CallbackRouter) register(action string, handler CallbackHandler) {
+	r.handlers[action] = handler
+}
+
+// callbackData encodes the action and its args as <version>:<action>:<args>,
+// Telegram limits the data to 64 bytes
+func callbackData(action string, args ...string) string {
+	return strings.Join(append([]string{callbackVersion, action}, args...), ":")
+}
+
+// parseCallbackData decodes the data of a button, the bool is false when the
+// data was encoded by another version
+func parseCallbackData(data string) (string, []string, bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) < 2 || parts[0] != callbackVersion {
+		return "", nil, false
+	}
+
+	return parts[1], parts[2:], true
+}
+
 func (b *TelegramBot) RegisterRoutes() {
 	b.router = NewCommandRouter()
 	b.router.register("reply", b.Reply)
 	b.router.register("help", b.Help)
+	b.router.register("cancel", b.Cancel)
 
 	// User handlers
 	b.router.register("user", b.GetUser)
 	b.router.register("user_add", b.CreateUser)
 	b.router.register("user_del", b.DeleteUser, b.RequireAdmin)
+	b.router.register("user_restore", b.RestoreUser, b.RequireAdmin)
+	b.router.register("reminders", b.SetReminders)
+	b.router.register("pix_key", b.SetPixKey, b.RequireAdmin)
+
+	// Group handlers
+	b.router.register("group_claim", b.ClaimLegacyBillings, b.RequireAdmin)
+	b.router.register("group_currency", b.SetGroupCurrency, b.RequireAdmin)
 
 	// Billing handlers
 	b.router.register("billing", b.GetBilling)
 	b.router.register("billing_list", b.ListBillings)
 	b.router.register("billing_add", b.CreateBilling, b.RequireAdmin)
+	b.router.register("billing_edit", b.EditBilling, b.RequireAdmin)
+	b.router.register("billing_history", b.BillingHistory)
+	b.router.register("billing_note", b.AddBillingNote)
 	b.router.register("billing_del", b.DeleteBilling, b.RequireAdmin)
+	b.router.register("billing_restore", b.RestoreBilling, b.RequireAdmin)
+	b.router.register("purge", b.Purge, b.RequireAdmin)
+	b.router.register("billing_export", b.ExportBillings)
+	b.router.register("billing_import", b.ImportBillings, b.RequireAdmin)
+
+	// Template handlers
+	b.router.register("template_add", b.CreateTemplate, b.RequireAdmin)
+	b.router.register("template_list", b.ListTemplates)
+	b.router.register("billing_from_template", b.CreateBillingFromTemplate, b.RequireAdmin)
 
 	// Payment handlers
 	b.router.register("payment_associate", b.AssociatePayment, b.RequireAdmin)
@@ -61,7 +122,50 @@ func (b *TelegramBot) RegisterRoutes() {
 	b.router.register("billing_unpay", b.UnpayBilling)
 	b.router.register("billing_pay_admin", b.PayBillingAdmin, b.RequireAdmin)
 	b.router.register("billing_unpay_admin", b.UnpayBillingAdmin, b.RequireAdmin)
+	b.router.register("proof_approve", b.ApprovePaymentProof, b.RequireAdmin)
+	b.router.register("proof_reject", b.RejectPaymentProof, b.RequireAdmin)
+
+	// Balance handlers
+	b.router.register("balances", b.Balances)
+	b.router.register("settle", b.Settle)
+
+	// Budget handlers
+	b.router.register("budget_add", b.CreateBudget, b.RequireAdmin)
+	b.router.register("budget_list", b.ListBudgets)
+	b.router.register("budget_status", b.BudgetStatus)
+
+	// Exchange rate handlers
+	b.router.register("rate_set", b.SetExchangeRate, b.RequireAdmin)
+	b.router.register("rate_list", b.ListExchangeRates)
+
+	// Report handlers
+	b.router.register("report", b.Report)
+
+	// Audit handlers
+	b.router.register("audit", b.Audit, b.RequireAdmin)
 
 	// Download handlers
 	b.router.register("youtube", b.DownloadYoutubeMidia)
+
+	b.flows = map[string]conversationFlow{
+		"billing_add": b.billingWizard(),
+	}
+
+	b.callbacks = NewCallbackRouter()
+	b.callbacks.register("answer", b.answerConversationCallback)
+
+	// Proof handlers
+	b.callbacks.register("proof_approve", b.approveProofCallback)
+	b.callbacks.register("proof_reject", b.rejectProofCallback)
+
+	// Import handlers
+	b.callbacks.register("import_confirm", b.confirmImportCallback)
+	b.callbacks.register("import_cancel", b.cancelImportCallback)
+
+	// Billing list handlers
+	b.callbacks.register("billing_view", b.commandCallback("billing"))
+	b.callbacks.register("billing_pay", b.commandCallback("billing_pay"))
+	b.callbacks.register("billing_unpay", b.commandCallback("billing_unpay"))
+	b.callbacks.register("billing_join", b.joinBillingCallback)
+	b.callbacks.register("billing_page", b.billingPageCallback)
 }
@@ -3,13 +3,17 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"misaki/config"
 	"misaki/internal/service"
 	"misaki/types"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -19,6 +23,21 @@ type TelegramBot struct {
 	service *service.Service
 	Bot     *tgbotapi.BotAPI
 	router  *CommandRouter
+
+	// Handlers of the inline buttons
+	callbacks *CallbackRouter
+
+	// Imports waiting for the admin confirmation
+	imports   map[uuid.UUID]*pendingImport
+	importsMu sync.Mutex
+
+	// Guided flows and the conversations answering them
+	flows           map[string]conversationFlow
+	conversations   map[conversationKey]*types.Conversation
+	conversationsMu sync.Mutex
+
+	// Period of the last monthly report posted
+	lastReport time.Time
 }
 
 func NewTelegramBot(config *config.Config, logger *zap.Logger, s *service.Service) *TelegramBot {
@@ -26,11 +45,19 @@ func NewTelegramBot(config *config.Config, logger *zap.Logger, s *service.Servic
 		logger:  logger,
 		config:  &config.Telegram,
 		service: s,
+		imports: map[uuid.UUID]*pendingImport{},
+
+		conversations: map[conversationKey]*types.Conversation{},
 	}
 }
 
 func (b *TelegramBot) StartBot() error {
-	bot, err := tgbotapi.NewBotAPI(b.config.Token)
+	endpoint := tgbotapi.APIEndpoint
+	if b.config.APIEndpoint != "" {
+		endpoint = b.config.APIEndpoint
+	}
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(b.config.Token, endpoint)
 	if err != nil {
 		return err
 	}
@@ -44,9 +71,21 @@ func (b *TelegramBot) StartBot() error {
 }
 
 func (b *TelegramBot) Handle(message *tgbotapi.Message) {
+	b.resolveCommand(message)
+
+	// Messages that are not commands answer the flow the user is in
+	if !message.IsCommand() && b.continueConversation(context.Background(), message) {
+		return
+	}
+
 	if endpoint, ok := b.router.handlers[message.Command()]; ok {
 		b.logger.Info("Running command", zap.String("command", message.Command()))
-		ctx := context.Background()
+		ctx := service.WithAuditActor(context.Background(), types.AuditActor{
+			ChatID:     message.Chat.ID,
+			TelegramID: message.From.ID,
+			Command:    message.Command(),
+			Arguments:  message.CommandArguments(),
+		})
 
 		for _, handler := range endpoint.Middlewares {
 			if pass := handler(ctx, message); !pass {
@@ -60,6 +99,72 @@ func (b *TelegramBot) Handle(message *tgbotapi.Message) {
 	b.logger.Info("Unknown command", zap.String("command", message.Command()))
 }
 
+func (b *TelegramBot) HandleCallback(query *tgbotapi.CallbackQuery) {
+	actor := types.AuditActor{TelegramID: query.From.ID, Command: "callback", Arguments: query.Data}
+	if query.Message != nil {
+		actor.ChatID = query.Message.Chat.ID
+	}
+	ctx := service.WithAuditActor(context.Background(), actor)
+	b.logger.Info("Running callback", zap.String("data", query.Data))
+
+	action, args, ok := parseCallbackData(query.Data)
+	if !ok {
+		b.answerCallback(query, "⚠️ This button expired, run the command again")
+		return
+	}
+
+	handler, ok := b.callbacks.handlers[action]
+	if !ok {
+		b.logger.Info("Unknown callback", zap.String("data", query.Data))
+		b.answerCallback(query, "")
+		return
+	}
+
+	handler(ctx, query, args)
+}
+
+func (b *TelegramBot) answerCallback(query *tgbotapi.CallbackQuery, text string) {
+	if _, err := b.Bot.Request(tgbotapi.NewCallback(query.ID, text)); err != nil {
+		b.logger.Error("error while answering callback", zap.Error(err))
+	}
+}
+
+// replyCommands are the commands that can be continued by replying to the
+// message where the bot asked for more information
+var replyCommands = []string{"billing_pay", "proof_reject", "billing_import"}
+
+// resolveCommand turns commands sent as caption of a photo or document, and
+// replies to a bot prompt, into regular command messages
+func (b *TelegramBot) resolveCommand(message *tgbotapi.Message) {
+	if message.IsCommand() {
+		return
+	}
+
+	if message.Text == "" && message.Caption != "" {
+		message.Text = message.Caption
+		message.Entities = message.CaptionEntities
+		if message.IsCommand() {
+			return
+		}
+	}
+
+	prompt := message.ReplyToMessage
+	if prompt == nil || prompt.From == nil || prompt.From.ID != b.Bot.Self.ID {
+		return
+	}
+
+	// The prompt ends with the command to run, completed by the reply text
+	lines := strings.Split(strings.TrimSpace(prompt.Text), "\n")
+	command := lines[len(lines)-1]
+	name, _, _ := strings.Cut(strings.TrimPrefix(command, "/"), " ")
+	if !strings.HasPrefix(command, "/") || !slices.Contains(replyCommands, name) {
+		return
+	}
+
+	message.Text = strings.TrimSpace(command + " " + message.Text)
+	message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(name) + 1}}
+}
+
 func (b *TelegramBot) RequireAdmin(ctx context.Context, m *tgbotapi.Message) bool {
 	admin, err := b.service.IsUserAdmin(ctx, &types.User{TelegramID: m.From.ID})
 	if err != nil {
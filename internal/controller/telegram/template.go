@@ -0,0 +1,259 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"misaki/internal/money"
+	"misaki/internal/service"
+	"misaki/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func (b *TelegramBot) CreateTemplate(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Split(m.CommandArguments(), " ")
+
+	if len(data) < 3 {
+		b.logger.Error("invalid template arguments", zap.Int("number arguments", len(data)))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <name> <value> users=<user-identifier[:share],...> [currency=<ISO-4217>] [payer=<user-identifier>] [category=<name>]")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	template, err := b.parseTemplate(ctx, m, data)
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ %s", err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	template, err = b.service.CreateTemplate(ctx, template)
+	if err != nil {
+		b.logger.Error("error creating template", zap.String("name", data[0]), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error creating template %s: %s", data[0], err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := "📋 *Template Created Successfully!*\n\n" + b.formatTemplate(template) +
+		fmt.Sprintf("\nUse /billing\\_from\\_template `%s` [value] to create its billings", template.Name)
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+// parseTemplate reads the /template_add arguments, users are informed as
+// identifiers optionally followed by their share, like bob:40%
+func (b *TelegramBot) parseTemplate(ctx context.Context, m *tgbotapi.Message, data []string) (*types.Template, error) {
+	options, err := b.parseOptions(data[2:], "users", "currency", "payer", "category")
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := money.NormalizeCurrency(options["currency"])
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := money.Parse(data[1], currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for template, expected decimal, received: %s", data[1])
+	}
+
+	template := &types.Template{
+		ChatID:   m.Chat.ID,
+		Name:     data[0],
+		Value:    value,
+		Currency: currency,
+		Category: options["category"],
+	}
+
+	if payer, ok := options["payer"]; ok {
+		user, err := b.findUser(ctx, payer)
+		if err != nil {
+			return nil, err
+		}
+		template.PayerID = user.UserID
+	}
+
+	users, ok := options["users"]
+	if !ok {
+		return nil, fmt.Errorf("missing template users, expected: users=<user-identifier[:share],...>")
+	}
+
+	for _, participant := range strings.Split(users, ",") {
+		id, rawShare, hasShare := strings.Cut(participant, ":")
+
+		user, err := b.findUser(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		templateUser := types.TemplateUser{
+			UserID:   user.UserID,
+			UserInfo: *user,
+		}
+		if hasShare {
+			templateUser.Share, err = service.ParseShare(rawShare, currency)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		template.Users = append(template.Users, templateUser)
+	}
+
+	return template, nil
+}
+
+func (b *TelegramBot) ListTemplates(ctx context.Context, m *tgbotapi.Message) {
+	templates, err := b.service.ListTemplates(ctx, m.Chat.ID)
+	if err != nil {
+		b.logger.Error("failed to list templates", zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Internal error while getting templates")
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := fmt.Sprintf("📋 *Templates Found:* %d\n\n", len(templates))
+	for _, template := range templates {
+		messageText += b.formatTemplate(template) + "\n"
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+func (b *TelegramBot) CreateBillingFromTemplate(ctx context.Context, m *tgbotapi.Message) {
+	data := strings.Fields(m.CommandArguments())
+
+	if len(data) < 1 || len(data) > 2 {
+		msg := tgbotapi.NewMessage(m.Chat.ID, "⚠️ Invalid number of arguments received, expected: <template> [value]")
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	template, err := b.service.GetTemplate(ctx, &types.Template{Name: data[0], ChatID: m.Chat.ID})
+	if err != nil {
+		b.logger.Error("failed to get template", zap.String("name", data[0]), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Internal error while getting template: %s", data[0]))
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Template %s not found", data[0]))
+		}
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	// Values are informed in the currency of the template
+	var value int64
+	if len(data) == 2 {
+		value, err = money.Parse(data[1], template.Currency)
+		if err != nil || value <= 0 {
+			msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Invalid value for billing, expected decimal, received: %s", data[1]))
+			msg.ReplyToMessageID = m.MessageID
+			if _, err := b.Bot.Send(msg); err != nil {
+				b.logger.Error("error while sending message", zap.Error(err))
+			}
+			return
+		}
+	}
+
+	billing, err := b.service.CreateBillingFromTemplate(ctx, template, value)
+	if err != nil {
+		b.logger.Error("error creating billing from template", zap.String("template", template.Name), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error creating billing from template %s: %s", template.Name, err.Error()))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	messageText := fmt.Sprintf(
+		"🤑 *Billing Created Successfully!*\n\n"+
+			"💰 *Billing Details:*\n"+
+			"🆔 *ID:* `%s`\n"+
+			"💬 *Name:* `%s`\n"+
+			"📋 *Template:* `%s`\n"+
+			"💸 *Value:* %s\n"+
+			"🏦 *Payer:* %s\n"+
+			"🗂 *Category:* %s\n\n",
+		billing.ID.String(),
+		billing.Name,
+		template.Name,
+		money.Format(billing.Value, billing.Currency),
+		b.formatPayer(billing),
+		b.formatCategory(billing),
+	)
+
+	for _, payment := range billing.Payments {
+		messageText += fmt.Sprintf("👤 `%s` %s (%s)\n",
+			b.getUserName(&payment.UserInfo),
+			money.Format(payment.Amount, billing.Currency),
+			b.formatShare(payment.Share, billing.Currency),
+		)
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, messageText)
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+
+	b.SendBudgetAlerts(ctx)
+}
+
+func (b *TelegramBot) formatTemplate(template *types.Template) string {
+	category := "-"
+	if template.Category != "" {
+		category = fmt.Sprintf("`%s`", template.Category)
+	}
+
+	users := make([]string, 0, len(template.Users))
+	for _, user := range template.Users {
+		users = append(users, fmt.Sprintf("`%s` (%s)", b.getUserName(&user.UserInfo), b.formatShare(user.Share, template.Currency)))
+	}
+
+	return fmt.Sprintf(
+		"📋 *Name:* `%s`\n"+
+			"💸 *Value:* %s\n"+
+			"🗂 *Category:* %s\n"+
+			"👤 *Users:* %s\n",
+		template.Name,
+		money.Format(template.Value, template.Currency),
+		category,
+		strings.Join(users, ", "),
+	)
+}
@@ -21,12 +21,23 @@ func (b *TelegramBot) CreateUser(ctx context.Context, m *tgbotapi.Message) {
 	// Set user admin
 	newUser.Admin = m.From.ID == b.config.AdminUser
 
-	user, err := b.service.CreateUser(ctx, &newUser)
+	// Users already registered by another group only join this one
+	title := "🎉 *User Joined the Group!*"
+	user, err := b.service.GetUser(ctx, &types.User{TelegramID: m.From.ID})
+	if err == sql.ErrNoRows {
+		title = "🎉 *User Created Successfully!*"
+		user, err = b.service.CreateUser(ctx, &newUser)
+	}
+
+	if err == nil {
+		err = b.service.JoinGroup(ctx, b.chatGroup(m), user)
+	}
+
 	if err != nil {
 
 		b.logger.Error("error creating user", zap.Int64("TelegramID", newUser.TelegramID), zap.String("Name", newUser.TelegramName), zap.Error(err))
 
-		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error creating user %s (%d)", newUser.TelegramName, newUser.TelegramID))
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error creating user %s (%d): %s", newUser.TelegramName, newUser.TelegramID, err.Error()))
 		msg.ReplyToMessageID = m.MessageID
 		if _, err := b.Bot.Send(msg); err != nil {
 			b.logger.Error("error while sending message", zap.Error(err))
@@ -35,7 +46,7 @@ func (b *TelegramBot) CreateUser(ctx context.Context, m *tgbotapi.Message) {
 	}
 
 	messageText := fmt.Sprintf(
-		"🎉 *User Created Successfully!*\n\n"+
+		title+"\n\n"+
 			"👤 *User Details:*\n"+
 			"🆔 *ID:* `%s`\n"+
 			"🌎 *Telegram ID:* `%d`\n"+
@@ -137,7 +148,46 @@ func (b *TelegramBot) DeleteUser(ctx context.Context, m *tgbotapi.Message) {
 
 		b.logger.Error("error deleting user", zap.Int64("TelegramID", user.TelegramID), zap.String("UserID", user.UserID.String()), zap.Error(err))
 
-		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error deleting user %s", id))
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error deleting user %s: %s", id, err.Error()))
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ User %s not found", id))
+		}
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("👤 *User Deleted:* %s\n\nUse /user\\_restore to undo it", id))
+	msg.ReplyToMessageID = m.MessageID
+	msg.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.Bot.Send(msg); err != nil {
+		b.logger.Error("error while sending message", zap.Error(err))
+	}
+}
+
+func (b *TelegramBot) RestoreUser(ctx context.Context, m *tgbotapi.Message) {
+	id := m.CommandArguments()
+
+	user, err := b.parseUserIdentifier(id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error to get user, invalid id informed: %s", id))
+		msg.ReplyToMessageID = m.MessageID
+		if _, err := b.Bot.Send(msg); err != nil {
+			b.logger.Error("error while sending message", zap.Error(err))
+		}
+		return
+	}
+
+	user, err = b.service.RestoreUser(ctx, user)
+	if err != nil {
+		b.logger.Error("error restoring user", zap.String("ID", id), zap.Error(err))
+
+		msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ Error restoring user %s: %s", id, err.Error()))
+		if err == sql.ErrNoRows {
+			msg = tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("⚠️ User %s not found", id))
+		}
 		msg.ReplyToMessageID = m.MessageID
 		if _, err := b.Bot.Send(msg); err != nil {
 			b.logger.Error("error while sending message", zap.Error(err))
@@ -145,7 +195,7 @@ func (b *TelegramBot) DeleteUser(ctx context.Context, m *tgbotapi.Message) {
 		return
 	}
 
-	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("👤 *User Deleted:* %s", id))
+	msg := tgbotapi.NewMessage(m.Chat.ID, fmt.Sprintf("👤 *User Restored:* `%s`", b.getUserName(user)))
 	msg.ReplyToMessageID = m.MessageID
 	msg.ParseMode = tgbotapi.ModeMarkdown
 	if _, err := b.Bot.Send(msg); err != nil {
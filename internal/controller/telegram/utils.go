@@ -1,10 +1,16 @@
 package telegram
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"misaki/internal/money"
+	"misaki/internal/service"
 	"misaki/types"
 
 	"github.com/google/uuid"
@@ -30,8 +36,24 @@ func (b *TelegramBot) parseUserIdentifier(id string) (*types.User, error) {
 	return user, nil
 }
 
-func (b *TelegramBot) parseBillingIdentifier(id string) (*types.Billing, error) {
-	billing := &types.Billing{}
+// findUser parses an user identifier and searches the user
+func (b *TelegramBot) findUser(ctx context.Context, id string) (*types.User, error) {
+	user, err := b.parseUserIdentifier(id)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err = b.service.GetUser(ctx, user)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user %s not found", id)
+	}
+	return user, err
+}
+
+// parseBillingIdentifier parses a billing id or name, names are searched in
+// the group of the chat
+func (b *TelegramBot) parseBillingIdentifier(chatID int64, id string) (*types.Billing, error) {
+	billing := &types.Billing{ChatID: chatID}
 
 	id = strings.TrimSpace(id)
 	if id == "" {
@@ -59,3 +81,151 @@ func (b *TelegramBot) getUserName(user *types.User) string {
 
 	return user.UserID.String()
 }
+
+// parseOptions parses optional command arguments in the key=value format
+func (b *TelegramBot) parseOptions(args []string, allowed ...string) (map[string]string, error) {
+	options := map[string]string{}
+
+	for _, arg := range args {
+		if arg == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid option %s, expected: <key>=<value>", arg)
+		}
+
+		if !slices.Contains(allowed, key) {
+			return nil, fmt.Errorf("unknown option %s, allowed: %s", key, strings.Join(allowed, ", "))
+		}
+
+		options[key] = value
+	}
+
+	return options, nil
+}
+
+func (b *TelegramBot) formatRecurrence(billing *types.Billing) string {
+	switch {
+	case billing.Recurrence == types.RecurrenceMonthly && billing.RecurrenceDay > 0:
+		return fmt.Sprintf("%s (day %d)", billing.Recurrence, billing.RecurrenceDay)
+	case billing.Recurrence != types.RecurrenceNone:
+		return string(billing.Recurrence)
+	}
+	return "none"
+}
+
+// parseDate parses dates in the YYYY-MM-DD format, "none" clears the date
+func (b *TelegramBot) parseDate(value string) (time.Time, error) {
+	if value == "none" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %s, expected: YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+func (b *TelegramBot) formatDate(date time.Time) string {
+	if date.IsZero() {
+		return "-"
+	}
+	return date.Format("2006-01-02")
+}
+
+func (b *TelegramBot) formatShare(share types.Share, currency string) string {
+	switch share.Type {
+	case types.SharePercent:
+		return fmt.Sprintf("%g%%", share.Value)
+	case types.ShareFixed:
+		return fmt.Sprintf("fixed %s", money.Format(share.Amount, currency))
+	case types.ShareWeight:
+		return fmt.Sprintf("weight %g", share.Value)
+	}
+	return "equal"
+}
+
+// formatValuePerUser shows the amount allocated to each user of an equal
+// split, the cents left by the division make some users pay one more cent
+func (b *TelegramBot) formatValuePerUser(billing *types.Billing) string {
+	if len(billing.Payments) == 0 {
+		return money.Format(0, billing.Currency)
+	}
+
+	lowest, highest := billing.Payments[0].Amount, billing.Payments[0].Amount
+	for _, payment := range billing.Payments {
+		if payment.Share.Type != types.ShareEqual {
+			return "custom shares"
+		}
+		lowest = min(lowest, payment.Amount)
+		highest = max(highest, payment.Amount)
+	}
+
+	if lowest == highest {
+		return money.Format(lowest, billing.Currency)
+	}
+	return fmt.Sprintf("%s to %s", money.FormatValue(lowest, billing.Currency), money.Format(highest, billing.Currency))
+}
+
+// formatEntryAmount renders ledger amounts with explicit sign
+func (b *TelegramBot) formatEntryAmount(amount int64, currency string) string {
+	if amount > 0 {
+		return "+" + money.Format(amount, currency)
+	}
+	return money.Format(amount, currency)
+}
+
+// formatEntryFee renders the late fees paid by a ledger entry
+func (b *TelegramBot) formatEntryFee(fee int64, currency string) string {
+	if fee == 0 {
+		return ""
+	}
+	return fmt.Sprintf("(late fee %s) ", b.formatEntryAmount(fee, currency))
+}
+
+func (b *TelegramBot) formatLateFee(billing *types.Billing) string {
+	policy := service.FormatLateFee(billing.LateFee, billing.Currency)
+	if policy == "" {
+		return "-"
+	}
+	return policy
+}
+
+func (b *TelegramBot) formatPayer(billing *types.Billing) string {
+	if billing.Payer == nil {
+		return "-"
+	}
+	return fmt.Sprintf("`%s`", b.getUserName(billing.Payer))
+}
+
+func (b *TelegramBot) formatPaymentStatus(status types.PaymentStatus) string {
+	switch status {
+	case types.PaymentPaid:
+		return "paid"
+	case types.PaymentPendingReview:
+		return "pending review"
+	}
+	return "unpaid"
+}
+
+func (b *TelegramBot) formatCategory(billing *types.Billing) string {
+	if billing.Category == "" {
+		return "-"
+	}
+	return fmt.Sprintf("`%s`", billing.Category)
+}
+
+func (b *TelegramBot) formatTags(billing *types.Billing) string {
+	if len(billing.Tags) == 0 {
+		return "-"
+	}
+
+	tags := make([]string, 0, len(billing.Tags))
+	for _, tag := range billing.Tags {
+		tags = append(tags, fmt.Sprintf("`#%s`", tag))
+	}
+	return strings.Join(tags, " ")
+}
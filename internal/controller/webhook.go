@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// webhookSecretHeader carries the secret sent to Telegram when the
+	// webhook was registered
+	webhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+	// webhookBuffer holds the updates received while a handler is running
+	webhookBuffer = 100
+)
+
+// StartWebhook starts the server receiving the updates and registers its URL
+// with Telegram, the updates are handled in order like in polling mode
+func (c *controller) StartWebhook() error {
+	webhook := c.config.Telegram.Webhook
+	if webhook.Secret == "" {
+		return fmt.Errorf("telegram webhook requires a secret")
+	}
+
+	if webhook.Listen == "" {
+		return fmt.Errorf("telegram webhook requires the address to listen on")
+	}
+
+	if (webhook.Cert == "") != (webhook.Key == "") {
+		return fmt.Errorf("telegram webhook requires both the certificate and the key to serve HTTPS")
+	}
+
+	address, err := url.Parse(webhook.URL)
+	if err != nil {
+		return fmt.Errorf("invalid telegram webhook url: %w", err)
+	}
+
+	path := address.Path
+	if path == "" {
+		path = "/"
+	}
+
+	listener, err := net.Listen("tcp", webhook.Listen)
+	if err != nil {
+		return err
+	}
+
+	// Certificates are loaded before serving so invalid files fail the start
+	var tlsConfig *tls.Config
+	if webhook.Cert != "" {
+		certificate, err := tls.LoadX509KeyPair(webhook.Cert, webhook.Key)
+		if err != nil {
+			listener.Close()
+			return fmt.Errorf("invalid telegram webhook certificate: %w", err)
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	}
+
+	updates := make(chan tgbotapi.Update, webhookBuffer)
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, c.receiveUpdate(updates))
+
+	c.webhook = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second, TLSConfig: tlsConfig}
+	c.webhookUpdates = updates
+
+	go func() {
+		serve := c.webhook.Serve
+		if tlsConfig != nil {
+			serve = func(l net.Listener) error { return c.webhook.ServeTLS(l, "", "") }
+		}
+
+		if err := serve(listener); err != nil && err != http.ErrServerClosed {
+			c.logger.Error("webhook server failed", zap.Error(err))
+		}
+	}()
+	go c.handleUpdates(updates)
+
+	params := tgbotapi.Params{"url": webhook.URL, "secret_token": webhook.Secret}
+	if _, err := c.telegramBot.Bot.MakeRequest("setWebhook", params); err != nil {
+		// fx does not stop a failed start, the server and the handler
+		// goroutine are stopped here instead
+		if err := c.webhook.Shutdown(context.Background()); err != nil {
+			c.logger.Error("failed to stop webhook server", zap.Error(err))
+		}
+		close(updates)
+		c.webhook, c.webhookUpdates = nil, nil
+
+		return fmt.Errorf("failed to register telegram webhook: %w", err)
+	}
+
+	c.logger.Info("Webhook registered", zap.String("listen", listener.Addr().String()), zap.String("path", path), zap.Bool("tls", webhook.Cert != ""))
+	return nil
+}
+
+// StopWebhook deletes the webhook so polling works again and stops the server
+// after the requests being received
+func (c *controller) StopWebhook(ctx context.Context) error {
+	if c.webhook == nil {
+		return nil
+	}
+
+	if _, err := c.telegramBot.Bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		c.logger.Error("failed to delete telegram webhook", zap.Error(err))
+	}
+
+	if err := c.webhook.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	// No request is queuing updates after the shutdown
+	close(c.webhookUpdates)
+	return nil
+}
+
+// receiveUpdate queues the updates posted by Telegram, requests without the
+// secret are rejected
+func (c *controller) receiveUpdate(updates chan<- tgbotapi.Update) http.HandlerFunc {
+	secret := []byte(c.config.Telegram.Webhook.Secret)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(webhookSecretHeader)), secret) != 1 {
+			c.logger.Warn("webhook request with invalid secret", zap.String("remote", r.RemoteAddr))
+			http.Error(w, "invalid secret token", http.StatusUnauthorized)
+			return
+		}
+
+		update, err := c.telegramBot.Bot.HandleUpdate(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		updates <- *update
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"misaki/config"
+	"misaki/internal/controller/telegram"
+
+	"go.uber.org/zap"
+)
+
+const (
+	testSecret = "s3cret"
+	testUpdate = `{"update_id":1,"message":{"message_id":3,"from":{"id":5},"chat":{"id":1},"date":0,"text":"/help","entities":[{"type":"bot_command","offset":0,"length":5}]}}`
+)
+
+// fakeTelegram records the methods called on the Bot API, setWebhook answers
+// with failure when failWebhook is set
+type fakeTelegram struct {
+	mu          sync.Mutex
+	calls       []string
+	failWebhook bool
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	f.mu.Lock()
+	f.calls = append(f.calls, method+" "+r.Form.Encode())
+	failWebhook := f.failWebhook
+	f.mu.Unlock()
+
+	switch {
+	case method == "getMe":
+		fmt.Fprint(w, `{"ok":true,"result":{"id":999,"is_bot":true,"first_name":"bot"}}`)
+	case method == "setWebhook" && failWebhook:
+		fmt.Fprint(w, `{"ok":false,"error_code":400,"description":"Bad Request: bad webhook"}`)
+	case strings.HasSuffix(method, "Webhook"):
+		fmt.Fprint(w, `{"ok":true,"result":true}`)
+	default:
+		fmt.Fprint(w, `{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":1}}}`)
+	}
+}
+
+// called returns the parameters of the calls to the method
+func (f *fakeTelegram) called(method string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	params := []string{}
+	for _, call := range f.calls {
+		if name, values, _ := strings.Cut(call, " "); name == method {
+			params = append(params, values)
+		}
+	}
+	return params
+}
+
+func newWebhookController(t *testing.T, api *fakeTelegram) (*controller, string) {
+	t.Helper()
+
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	// Reserve a free port for the webhook server
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listen := listener.Addr().String()
+	listener.Close()
+
+	cfg := &config.Config{}
+	cfg.Telegram.Token = "token"
+	cfg.Telegram.APIEndpoint = server.URL + "/bot%s/%s"
+	cfg.Telegram.Webhook = config.Webhook{URL: "https://example.com/hook", Listen: listen, Secret: testSecret}
+
+	bot := telegram.NewTelegramBot(cfg, zap.NewNop(), nil)
+	if err := bot.StartBot(); err != nil {
+		t.Fatal(err)
+	}
+	bot.RegisterRoutes()
+
+	return NewController(cfg, zap.NewNop(), nil, bot), "http://" + listen + "/hook"
+}
+
+func postUpdate(t *testing.T, url, secret string) int {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(testUpdate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(webhookSecretHeader, secret)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestWebhook(t *testing.T) {
+	api := &fakeTelegram{}
+	c, url := newWebhookController(t, api)
+
+	if err := c.StartWebhook(); err != nil {
+		t.Fatal(err)
+	}
+
+	if set := api.called("setWebhook"); len(set) != 1 || set[0] != "secret_token=s3cret&url=https%3A%2F%2Fexample.com%2Fhook" {
+		t.Fatalf("setWebhook calls: %v", set)
+	}
+
+	for _, secret := range []string{"", "wrong"} {
+		if code := postUpdate(t, url, secret); code != http.StatusUnauthorized {
+			t.Fatalf("secret %q: expected status 401, received %d", secret, code)
+		}
+	}
+
+	if code := postUpdate(t, url, testSecret); code != http.StatusOK {
+		t.Fatalf("expected status 200, received %d", code)
+	}
+
+	// The /help handler answers through the fake API
+	deadline := time.Now().Add(2 * time.Second)
+	for len(api.called("sendMessage")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("update did not reach the handler")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sent := api.called("sendMessage"); len(sent) != 1 {
+		t.Fatalf("expected one message, sent %d", len(sent))
+	}
+
+	if err := c.StopWebhook(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if deleted := api.called("deleteWebhook"); len(deleted) != 1 {
+		t.Fatalf("deleteWebhook calls: %v", deleted)
+	}
+}
+
+func TestWebhookRegisterFailure(t *testing.T) {
+	api := &fakeTelegram{failWebhook: true}
+	c, url := newWebhookController(t, api)
+
+	if err := c.StartWebhook(); err == nil {
+		t.Fatal("expected error registering the webhook")
+	}
+
+	if c.webhook != nil || c.webhookUpdates != nil {
+		t.Fatal("webhook server kept after the failed start")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(testUpdate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		resp.Body.Close()
+		t.Fatal("webhook server still listening")
+	}
+}
+
+func TestWebhookConfig(t *testing.T) {
+	tests := map[string]config.Webhook{
+		"missing secret": {URL: "https://example.com/hook", Listen: "127.0.0.1:0"},
+		"missing listen": {URL: "https://example.com/hook", Secret: testSecret},
+		"missing key":    {URL: "https://example.com/hook", Listen: "127.0.0.1:0", Secret: testSecret, Cert: "cert.pem"},
+	}
+
+	for name, webhook := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Telegram.Webhook = webhook
+
+			c := NewController(cfg, zap.NewNop(), nil, nil)
+			if err := c.StartWebhook(); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
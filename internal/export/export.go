@@ -0,0 +1,151 @@
+// Package export writes billings and their payments as spreadsheets
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ParseFormat validates the spreadsheet format, empty formats use CSV
+func ParseFormat(format string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(format))) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	}
+	return "", fmt.Errorf("unsupported export format: %s, expected: csv or xlsx", format)
+}
+
+var header = []string{
+	"billing_id", "billing", "currency", "value", "created_at", "due_at", "payer",
+	"user_id", "user", "share", "amount", "paid_amount", "outstanding", "status", "paid_at",
+}
+
+// numeric are the columns written as numbers in XLSX files
+var numeric = map[int]bool{3: true, 10: true, 11: true, 12: true}
+
+// Rows builds one row per user associated with each billing, billings without
+// users have a single row with the user columns empty. Billings must be
+// loaded with Service.GetBilling
+func Rows(billings []*types.Billing) [][]string {
+	rows := [][]string{header}
+
+	for _, billing := range billings {
+		payer := ""
+		if billing.Payer != nil {
+			payer = userName(billing.Payer)
+		}
+
+		columns := []string{
+			billing.ID.String(),
+			billing.Name,
+			billing.Currency,
+			money.FormatValue(billing.Value, billing.Currency),
+			formatTime(billing.CreatedAt),
+			formatTime(billing.DueAt),
+			payer,
+		}
+
+		if len(billing.Payments) == 0 {
+			rows = append(rows, append(columns, make([]string, len(header)-len(columns))...))
+			continue
+		}
+
+		for _, payment := range billing.Payments {
+			row := append([]string{}, columns...)
+			row = append(row,
+				payment.UserID.String(),
+				userName(&payment.UserInfo),
+				formatShare(payment.Share, billing.Currency),
+				money.FormatValue(payment.Amount, billing.Currency),
+				money.FormatValue(payment.PaidAmount, billing.Currency),
+				money.FormatValue(payment.Outstanding, billing.Currency),
+				string(payment.Status),
+				formatTime(payment.PaidAt),
+			)
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// Write writes the billings spreadsheet in the format informed
+func Write(w io.Writer, format Format, billings []*types.Billing) error {
+	rows := Rows(billings)
+	if format == FormatXLSX {
+		return writeXLSX(w, rows)
+	}
+	return writeCSV(w, rows)
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, value := range row {
+			cells[j] = value
+			if i > 0 && !numeric[j] {
+				cells[j] = escapeFormula(value)
+			}
+		}
+
+		if err := writer.Write(cells); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// escapeFormula prefixes text that spreadsheets would evaluate as a formula
+// with a quote, so names chosen by users cannot run formulas when opened
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func userName(user *types.User) string {
+	if user.TelegramName != "" {
+		return user.TelegramName
+	}
+	if user.TelegramID != types.TELEGRAM_ID_EMPTY {
+		return fmt.Sprintf("%d", user.TelegramID)
+	}
+	return user.UserID.String()
+}
+
+func formatShare(share types.Share, currency string) string {
+	switch share.Type {
+	case types.SharePercent:
+		return fmt.Sprintf("%g%%", share.Value)
+	case types.ShareFixed:
+		return "fixed:" + money.FormatValue(share.Amount, currency)
+	case types.ShareWeight:
+		return fmt.Sprintf("weight:%g", share.Value)
+	}
+	return "equal"
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,57 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	billing := &types.Billing{
+		ID:       uuid.New(),
+		Name:     "=HYPERLINK(\"http://example.com\")",
+		Currency: "BRL",
+		Value:    1000,
+		Payer:    &types.User{TelegramName: "@payer"},
+		Payments: []types.Payment{
+			{UserInfo: types.User{TelegramName: "+5511"}, Amount: 500},
+			{UserInfo: types.User{TelegramName: "-cmd"}, Amount: 500},
+			{UserInfo: types.User{TelegramName: "maria"}, Amount: 0},
+		},
+	}
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, FormatCSV, []*types.Billing{billing}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0][1] != "billing" {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+
+	tests := []struct {
+		row, column int
+		want        string
+	}{
+		{row: 1, column: 1, want: "'=HYPERLINK(\"http://example.com\")"},
+		{row: 1, column: 3, want: "10.00"},
+		{row: 1, column: 6, want: "'@payer"},
+		{row: 1, column: 8, want: "'+5511"},
+		{row: 2, column: 8, want: "'-cmd"},
+		{row: 3, column: 8, want: "maria"},
+	}
+
+	for _, test := range tests {
+		if got := rows[test.row][test.column]; got != test.want {
+			t.Errorf("row %d column %d = %q, expected %q", test.row, test.column, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package money handles monetary values stored as integer amounts of the
+// currency minor unit (e.g. cents), avoiding floating point rounding errors
+package money
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const DefaultCurrency = "BRL"
+
+// exponents maps ISO-4217 currency codes to the number of digits of their
+// minor unit
+var exponents = map[string]int{
+	"ARS": 2, "AUD": 2, "BHD": 3, "BOB": 2, "BRL": 2, "CAD": 2, "CHF": 2,
+	"CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2,
+	"HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2, "ISK": 0, "JOD": 3,
+	"JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2, "NOK": 2, "NZD": 2, "OMR": 3,
+	"PEN": 2, "PLN": 2, "PYG": 0, "RUB": 2, "SEK": 2, "SGD": 2, "THB": 2,
+	"TND": 3, "TRY": 2, "TWD": 2, "UAH": 2, "USD": 2, "UYU": 2, "VND": 0,
+	"ZAR": 2,
+}
+
+// NormalizeCurrency validates an ISO-4217 code, empty codes use the default
+func NormalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency, nil
+	}
+
+	if _, ok := exponents[currency]; !ok {
+		return "", fmt.Errorf("unsupported currency: %s", currency)
+	}
+	return currency, nil
+}
+
+// Exponent returns the number of digits of the currency minor unit
+func Exponent(currency string) int {
+	if exponent, ok := exponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// Parse converts a decimal value such as 10.5 or 10,50 to minor units,
+// values with more decimal places than the currency supports are rejected
+func Parse(value, currency string) (int64, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", ".")
+	if value == "" {
+		return 0, fmt.Errorf("empty value")
+	}
+
+	negative := strings.HasPrefix(value, "-")
+	if negative || strings.HasPrefix(value, "+") {
+		value = value[1:]
+	}
+
+	integer, fraction, _ := strings.Cut(value, ".")
+	digits := integer + fraction
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid value: %s", value)
+	}
+
+	exponent := Exponent(currency)
+	if len(fraction) > exponent {
+		return 0, fmt.Errorf("invalid value %s, %s supports up to %d decimal places", value, currency, exponent)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	if integer == "" {
+		integer = "0"
+	}
+	amount, err := strconv.ParseInt(integer+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value: %s", value)
+	}
+
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// Format renders an amount in minor units as a decimal value with its currency
+func Format(amount int64, currency string) string {
+	return FormatValue(amount, currency) + " " + currency
+}
+
+// FormatValue renders an amount in minor units as a decimal value
+func FormatValue(amount int64, currency string) string {
+	exponent := Exponent(currency)
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	if exponent == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+
+	unit := int64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, exponent, amount%unit)
+}
+
+// Float converts an amount to a decimal value, it must only be used to
+// display values since it is subject to rounding errors
+func Float(amount int64, currency string) float64 {
+	return float64(amount) / math.Pow10(Exponent(currency))
+}
+
+// Convert converts an amount in minor units of from into minor units of to,
+// rate is how many units of to are worth one unit of from. The result is
+// rounded half away from zero
+func Convert(amount int64, from, to string, rate float64) int64 {
+	value := float64(amount) * rate * math.Pow10(Exponent(to)-Exponent(from))
+	return int64(math.Round(value))
+}
+
+// Allocate distributes total proportionally to weights using the largest
+// remainder method, so the parts always sum up to total. Ties are broken by
+// position, making the distribution deterministic
+func Allocate(total int64, weights []float64) []int64 {
+	parts := make([]int64, len(weights))
+
+	sum := 0.0
+	for _, weight := range weights {
+		sum += weight
+	}
+	if sum <= 0 || total == 0 {
+		return parts
+	}
+
+	type remainder struct {
+		index int
+		value float64
+	}
+	remainders := make([]remainder, len(weights))
+
+	allocated := int64(0)
+	for i, weight := range weights {
+		exact := float64(total) * weight / sum
+		parts[i] = int64(math.Floor(exact))
+		remainders[i] = remainder{index: i, value: exact - float64(parts[i])}
+		allocated += parts[i]
+	}
+
+	sort.SliceStable(remainders, func(i, j int) bool {
+		return remainders[i].value > remainders[j].value
+	})
+
+	for i := 0; allocated < total; i++ {
+		parts[remainders[i%len(remainders)].index]++
+		allocated++
+	}
+
+	return parts
+}
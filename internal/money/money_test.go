@@ -0,0 +1,137 @@
+package money
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     int64
+		invalid  bool
+	}{
+		{value: "10", currency: "BRL", want: 1000},
+		{value: "10.5", currency: "BRL", want: 1050},
+		{value: "10,50", currency: "BRL", want: 1050},
+		{value: " 0.01 ", currency: "USD", want: 1},
+		{value: ".5", currency: "BRL", want: 50},
+		{value: "3.", currency: "BRL", want: 300},
+		{value: "+7.25", currency: "BRL", want: 725},
+		{value: "-7.25", currency: "BRL", want: -725},
+		{value: "-0,5", currency: "EUR", want: -50},
+		{value: "1500", currency: "JPY", want: 1500},
+		{value: "1500.5", currency: "JPY", invalid: true},
+		{value: "1.234", currency: "KWD", want: 1234},
+		{value: "1.5", currency: "KWD", want: 1500},
+		{value: "-1.005", currency: "BHD", want: -1005},
+		{value: "1.2345", currency: "KWD", invalid: true},
+		{value: "10.555", currency: "BRL", invalid: true},
+		{value: "", currency: "BRL", invalid: true},
+		{value: "-", currency: "BRL", invalid: true},
+		{value: ".", currency: "BRL", invalid: true},
+		{value: "+-5", currency: "BRL", invalid: true},
+		{value: "--5", currency: "BRL", invalid: true},
+		{value: "1.2.3", currency: "KWD", invalid: true},
+		{value: "abc", currency: "BRL", invalid: true},
+		{value: "1e3", currency: "BRL", invalid: true},
+		{value: "99999999999999999999", currency: "BRL", invalid: true},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(test.value, test.currency)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("Parse(%q, %s) = %d, expected error", test.value, test.currency, got)
+			}
+			continue
+		}
+
+		if err != nil || got != test.want {
+			t.Errorf("Parse(%q, %s) = %d, %v, expected %d", test.value, test.currency, got, err, test.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{amount: 1050, currency: "BRL", want: "10.50 BRL"},
+		{amount: 5, currency: "USD", want: "0.05 USD"},
+		{amount: -725, currency: "BRL", want: "-7.25 BRL"},
+		{amount: 1500, currency: "JPY", want: "1500 JPY"},
+		{amount: -1500, currency: "JPY", want: "-1500 JPY"},
+		{amount: 1005, currency: "KWD", want: "1.005 KWD"},
+	}
+
+	for _, test := range tests {
+		if got := Format(test.amount, test.currency); got != test.want {
+			t.Errorf("Format(%d, %s) = %q, expected %q", test.amount, test.currency, got, test.want)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []float64
+		want    []int64
+	}{
+		{name: "even", total: 9000, weights: []float64{1, 1, 1}, want: []int64{3000, 3000, 3000}},
+		{name: "remainder to the first", total: 10000, weights: []float64{1, 1, 1}, want: []int64{3334, 3333, 3333}},
+		{name: "two remainders", total: 200, weights: []float64{1, 1, 1}, want: []int64{67, 67, 66}},
+		{name: "largest remainder", total: 100, weights: []float64{1, 2, 3}, want: []int64{17, 33, 50}},
+		{name: "weights", total: 1000, weights: []float64{2, 1, 1}, want: []int64{500, 250, 250}},
+		{name: "zero weight", total: 1000, weights: []float64{1, 0, 1}, want: []int64{500, 0, 500}},
+		{name: "fractional weights", total: 1001, weights: []float64{0.5, 0.5}, want: []int64{501, 500}},
+		{name: "negative total", total: -10000, weights: []float64{1, 1, 1}, want: []int64{-3333, -3333, -3334}},
+		{name: "zero total", total: 0, weights: []float64{1, 1}, want: []int64{0, 0}},
+		{name: "no weights", total: 1000, weights: []float64{0, 0}, want: []int64{0, 0}},
+		{name: "empty", total: 1000, weights: []float64{}, want: []int64{}},
+		{name: "single cent", total: 1, weights: []float64{1, 1, 1}, want: []int64{1, 0, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Allocate(test.total, test.weights)
+			if !slices.Equal(got, test.want) {
+				t.Fatalf("Allocate(%d, %v) = %v, expected %v", test.total, test.weights, got, test.want)
+			}
+
+			sum := int64(0)
+			for _, part := range got {
+				sum += part
+			}
+			if len(got) > 0 && slices.ContainsFunc(test.weights, func(w float64) bool { return w > 0 }) && sum != test.total {
+				t.Fatalf("parts sum %d, expected %d", sum, test.total)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		from, to string
+		rate     float64
+		want     int64
+	}{
+		{amount: 1000, from: "USD", to: "BRL", rate: 5.5, want: 5500},
+		{amount: 1000, from: "USD", to: "JPY", rate: 150, want: 1500},
+		{amount: 1500, from: "JPY", to: "USD", rate: 1.0 / 150, want: 1000},
+		{amount: 1, from: "BRL", to: "USD", rate: 0.5, want: 1},
+		{amount: -1, from: "BRL", to: "USD", rate: 0.5, want: -1},
+		{amount: 1000, from: "KWD", to: "USD", rate: 3.25, want: 325},
+	}
+
+	for _, test := range tests {
+		if got := Convert(test.amount, test.from, test.to, test.rate); got != test.want {
+			t.Errorf("Convert(%d, %s, %s, %g) = %d, expected %d", test.amount, test.from, test.to, test.rate, got, test.want)
+		}
+	}
+}
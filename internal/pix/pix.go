@@ -0,0 +1,135 @@
+// Package pix builds static PIX payment payloads following the EMV BR Code
+// specification published by the Banco Central do Brasil
+package pix
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"misaki/internal/money"
+)
+
+const (
+	gui = "br.gov.bcb.pix"
+
+	maxKeyLength  = 77
+	maxNameLength = 25
+	maxCityLength = 15
+	maxTxIDLength = 25
+)
+
+// Payload is a static PIX charge, Amount is in cents and may be zero to let
+// the payer choose the value
+type Payload struct {
+	Key    string
+	Name   string
+	City   string
+	Amount int64
+	TxID   string
+}
+
+// ValidateKey checks the size limits of a PIX key (e-mail, phone, CPF, CNPJ
+// or random key)
+func ValidateKey(key string) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("pix key cannot be empty")
+	}
+	if len(key) > maxKeyLength {
+		return fmt.Errorf("pix key must have at most %d characters", maxKeyLength)
+	}
+	if strings.ContainsAny(key, " \t\n") {
+		return fmt.Errorf("pix key cannot contain spaces")
+	}
+	return nil
+}
+
+// String encodes the payload as the "copia e cola" text, ending with the
+// CRC16 checksum of the whole content
+func (p Payload) String() string {
+	account := field("00", gui) + field("01", p.Key)
+
+	txID := sanitize(p.TxID, maxTxIDLength, false)
+	if txID == "" {
+		txID = "***"
+	}
+
+	// Name and city are mandatory, banks only display them
+	name := sanitize(p.Name, maxNameLength, true)
+	if name == "" {
+		name = "N"
+	}
+	city := sanitize(p.City, maxCityLength, true)
+	if city == "" {
+		city = "BRASIL"
+	}
+
+	payload := field("00", "01") +
+		field("26", account) +
+		field("52", "0000") +
+		field("53", "986")
+	if p.Amount > 0 {
+		payload += field("54", money.FormatValue(p.Amount, "BRL"))
+	}
+	payload += field("58", "BR") +
+		field("59", name) +
+		field("60", city) +
+		field("62", field("05", txID)) +
+		"6304"
+
+	return payload + fmt.Sprintf("%04X", crc16(payload))
+}
+
+// field encodes an EMV ID-length-value entry
+func field(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// sanitize keeps only the ASCII characters accepted by the BR Code fields,
+// removing accents and truncating the value to its maximum length
+func sanitize(value string, length int, spaces bool) string {
+	value = accents.Replace(strings.ToLower(strings.TrimSpace(value)))
+
+	var builder strings.Builder
+	for _, r := range value {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			builder.WriteRune(unicode.ToUpper(r))
+		case r == ' ' && spaces:
+			builder.WriteRune(r)
+		}
+	}
+
+	result := builder.String()
+	if len(result) > length {
+		result = result[:length]
+	}
+	return strings.TrimSpace(result)
+}
+
+// crc16 computes the CRC16-CCITT (polynomial 0x1021, initial value 0xFFFF)
+// required by the BR Code
+func crc16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
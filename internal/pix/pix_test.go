@@ -0,0 +1,91 @@
+package pix
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// bcbExample is the static BR Code published in the PIX manual of the Banco
+// Central do Brasil
+const bcbExample = "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D"
+
+func TestCRC16(t *testing.T) {
+	tests := []struct {
+		data string
+		want uint16
+	}{
+		// Check value of CRC-16/CCITT-FALSE
+		{data: "123456789", want: 0x29B1},
+		{data: "", want: 0xFFFF},
+		{data: strings.TrimSuffix(bcbExample, "1D3D"), want: 0x1D3D},
+	}
+
+	for _, test := range tests {
+		if got := crc16(test.data); got != test.want {
+			t.Errorf("crc16(%q) = %04X, expected %04X", test.data, got, test.want)
+		}
+	}
+}
+
+func TestPayloadString(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload Payload
+		want    string
+	}{
+		{
+			name:    "bcb example",
+			payload: Payload{Key: "123e4567-e12b-12d1-a456-426655440000", Name: "Fulano de Tal", City: "Brasília"},
+			// Names are sent in upper case, which changes the checksum
+			want: "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913FULANO DE TAL6008BRASILIA62070503***6304F012",
+		},
+		{
+			name:    "amount and transaction id",
+			payload: Payload{Key: "fulano@example.com", Name: "João da Silva", City: "São Paulo", Amount: 150050, TxID: "rent-march"},
+			want:    "00020126400014br.gov.bcb.pix0118fulano@example.com52040000530398654071500.505802BR5913JOAO DA SILVA6009SAO PAULO62130509RENTMARCH63047EA6",
+		},
+		{
+			name:    "empty name and city",
+			payload: Payload{Key: "+5511999999999"},
+			want:    "00020126360014br.gov.bcb.pix0114+55119999999995204000053039865802BR5901N6006BRASIL62070503***6304",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.payload.String()
+			if !strings.HasPrefix(got, test.want) {
+				t.Fatalf("payload\n%s\nexpected\n%s", got, test.want)
+			}
+
+			// Every payload ends with the checksum of the content before it
+			content, checksum := got[:len(got)-4], got[len(got)-4:]
+			if want := fmt.Sprintf("%04X", crc16(content)); checksum != want {
+				t.Fatalf("checksum %s, expected %s", checksum, want)
+			}
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		value  string
+		length int
+		spaces bool
+		want   string
+	}{
+		{value: "João Conceição", length: 25, spaces: true, want: "JOAO CONCEICAO"},
+		{value: "rent-march/2026", length: 25, spaces: false, want: "RENTMARCH2026"},
+		{value: "  Brasília  ", length: 15, spaces: true, want: "BRASILIA"},
+		{value: "Maria Aparecida dos Santos Silva", length: 25, spaces: true, want: "MARIA APARECIDA DOS SANTO"},
+		{value: "ab cd", length: 3, spaces: true, want: "AB"},
+		{value: "日本", length: 25, spaces: true, want: ""},
+	}
+
+	for _, test := range tests {
+		if got := sanitize(test.value, test.length, test.spaces); got != test.want {
+			t.Errorf("sanitize(%q) = %q, expected %q", test.value, got, test.want)
+		}
+	}
+}
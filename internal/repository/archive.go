@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"misaki/types"
+)
+
+// RestoreBilling restores the billing and the cycles deleted along with it
+func (s *SQLite) RestoreBilling(ctx context.Context, billing *types.Billing) error {
+	query := `UPDATE billings SET deleted_at = NULL WHERE (id = $1 OR id_parent = $1) AND deleted_at = $2`
+	_, err := s.conn.Exec(query, billing.ID, billing.DeletedAt)
+	return err
+}
+
+func (s *SQLite) RestoreUser(ctx context.Context, user *types.User) error {
+	query := `UPDATE users SET deleted_at = NULL WHERE id = $1`
+	_, err := s.conn.Exec(query, user.UserID)
+	return err
+}
+
+// PurgeDeleted permanently deletes the billings and users soft deleted
+// before the given time, returning how many of each were removed
+func (s *SQLite) PurgeDeleted(ctx context.Context, before time.Time) (billings int64, users int64, err error) {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	// The ledger restricts deleting associations, the entries of purged
+	// billings and users are removed first
+	query := `DELETE FROM payment_entries WHERE id_billing IN (SELECT id FROM billings WHERE deleted_at < $1)
+					OR id_user IN (SELECT id FROM users WHERE deleted_at < $1)`
+	if _, err = tx.Exec(query, before); err != nil {
+		return 0, 0, err
+	}
+
+	query = `DELETE FROM billings WHERE deleted_at < $1`
+	result, err := tx.Exec(query, before)
+	if err != nil {
+		return 0, 0, err
+	}
+	if billings, err = result.RowsAffected(); err != nil {
+		return 0, 0, err
+	}
+
+	query = `DELETE FROM users WHERE deleted_at < $1`
+	result, err = tx.Exec(query, before)
+	if err != nil {
+		return 0, 0, err
+	}
+	if users, err = result.RowsAffected(); err != nil {
+		return 0, 0, err
+	}
+
+	return billings, users, nil
+}
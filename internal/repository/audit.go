@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+func (s *SQLite) CreateAuditEntry(ctx context.Context, entry *types.AuditEntry) error {
+	query := `INSERT INTO audit_log (id, chat_id, actor_telegram_id, command, arguments, action, id_billing, billing_name, id_user, user_name, details, result, created_at)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+	_, err := s.conn.Exec(query,
+		entry.ID,
+		entry.ChatID,
+		entry.ActorTelegramID,
+		entry.Command,
+		entry.Arguments,
+		entry.Action,
+		nullUUID(entry.BillingID),
+		entry.BillingName,
+		nullUUID(entry.UserID),
+		entry.UserName,
+		entry.Details,
+		entry.Result,
+		entry.CreatedAt,
+	)
+	return err
+}
+
+// ListAuditEntries returns the latest entries matching the filter, newest first
+func (s *SQLite) ListAuditEntries(ctx context.Context, filter types.AuditFilter) ([]types.AuditEntry, error) {
+	query := `SELECT id, chat_id, actor_telegram_id, command, arguments, action, id_billing, billing_name, id_user, user_name, details, result, created_at
+					FROM audit_log WHERE chat_id = $1`
+	args := []any{filter.ChatID}
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.BillingID != uuid.Nil {
+		query += ` AND id_billing = ` + arg(filter.BillingID)
+	}
+
+	if filter.UserID != uuid.Nil {
+		query += ` AND (id_user = ` + arg(filter.UserID) + ` OR actor_telegram_id = ` + arg(filter.TelegramID) + `)`
+	}
+
+	query += ` ORDER BY created_at DESC LIMIT ` + arg(filter.Limit)
+
+	rows, err := s.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []types.AuditEntry{}
+	for rows.Next() {
+		entry := types.AuditEntry{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ChatID,
+			&entry.ActorTelegramID,
+			&entry.Command,
+			&entry.Arguments,
+			&entry.Action,
+			&entry.BillingID,
+			&entry.BillingName,
+			&entry.UserID,
+			&entry.UserName,
+			&entry.Details,
+			&entry.Result,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+
+	"misaki/types"
+)
+
+func (s *SQLite) CreateBudget(ctx context.Context, budget *types.Budget) error {
+	query := `INSERT INTO budgets (id, chat_id, category, amount, currency, period, created_at)
+					VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := s.conn.Exec(query,
+		budget.ID,
+		budget.ChatID,
+		budget.Category,
+		budget.Amount,
+		budget.Currency,
+		budget.Period,
+		budget.CreatedAt,
+	)
+	return err
+}
+
+// ListBudgets returns the budgets of the group, every budget when chatID is
+// zero
+func (s *SQLite) ListBudgets(ctx context.Context, chatID int64) ([]*types.Budget, error) {
+	query := `SELECT id, chat_id, category, amount, currency, period, alerted_period, alerted_percent, created_at FROM budgets`
+	args := []any{}
+	if chatID != 0 {
+		query += ` WHERE chat_id = $1`
+		args = append(args, chatID)
+	}
+
+	rows, err := s.conn.Query(query+` ORDER BY chat_id, category, period`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := []*types.Budget{}
+	for rows.Next() {
+		budget := &types.Budget{}
+		err := rows.Scan(
+			&budget.ID,
+			&budget.ChatID,
+			&budget.Category,
+			&budget.Amount,
+			&budget.Currency,
+			&budget.Period,
+			&budget.AlertedPeriod,
+			&budget.AlertedPercent,
+			&budget.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, rows.Err()
+}
+
+// UpdateBudgetAlert saves the last alert posted for the budget
+func (s *SQLite) UpdateBudgetAlert(ctx context.Context, budget *types.Budget) error {
+	query := `UPDATE budgets SET alerted_period = $1, alerted_percent = $2 WHERE id = $3`
+	_, err := s.conn.Exec(query, budget.AlertedPeriod, budget.AlertedPercent, budget.ID)
+	return err
+}
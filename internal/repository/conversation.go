@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"misaki/types"
+)
+
+// SaveConversation creates or replaces the conversation of the user in the
+// chat
+func (s *SQLite) SaveConversation(ctx context.Context, conversation *types.Conversation) error {
+	data, err := json.Marshal(conversation.Data)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO conversations (chat_id, telegram_id, flow, step, data, updated_at)
+					VALUES ($1, $2, $3, $4, $5, $6)
+					ON CONFLICT (chat_id, telegram_id) DO UPDATE SET
+						flow = excluded.flow, step = excluded.step, data = excluded.data, updated_at = excluded.updated_at`
+	_, err = s.conn.Exec(query,
+		conversation.ChatID,
+		conversation.TelegramID,
+		conversation.Flow,
+		conversation.Step,
+		string(data),
+		conversation.UpdatedAt,
+	)
+	return err
+}
+
+func (s *SQLite) DeleteConversation(ctx context.Context, chatID, telegramID int64) error {
+	query := `DELETE FROM conversations WHERE chat_id = $1 AND telegram_id = $2`
+	_, err := s.conn.Exec(query, chatID, telegramID)
+	return err
+}
+
+func (s *SQLite) ListConversations(ctx context.Context) ([]*types.Conversation, error) {
+	query := `SELECT chat_id, telegram_id, flow, step, data, updated_at FROM conversations`
+	rows, err := s.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversations := []*types.Conversation{}
+	for rows.Next() {
+		conversation := &types.Conversation{}
+		var data string
+		err := rows.Scan(
+			&conversation.ChatID,
+			&conversation.TelegramID,
+			&conversation.Flow,
+			&conversation.Step,
+			&data,
+			&conversation.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(data), &conversation.Data); err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, conversation)
+	}
+
+	return conversations, rows.Err()
+}
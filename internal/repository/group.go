@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// SaveGroup creates the group or updates its title
+func (s *SQLite) SaveGroup(ctx context.Context, group *types.Group) error {
+	query := `INSERT INTO groups (chat_id, title, created_at) VALUES ($1, $2, $3)
+					ON CONFLICT (chat_id) DO UPDATE SET title = excluded.title`
+	_, err := s.conn.Exec(query, group.ChatID, group.Title, group.CreatedAt)
+	return err
+}
+
+func (s *SQLite) GetGroup(ctx context.Context, chatID int64) (*types.Group, error) {
+	group := &types.Group{}
+	query := `SELECT chat_id, title, currency, created_at FROM groups WHERE chat_id = $1`
+	err := s.conn.QueryRow(query, chatID).Scan(&group.ChatID, &group.Title, &group.Currency, &group.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *SQLite) UpdateGroupCurrency(ctx context.Context, group *types.Group) error {
+	_, err := s.conn.Exec(`UPDATE groups SET currency = $1 WHERE chat_id = $2`, group.Currency, group.ChatID)
+	return err
+}
+
+func (s *SQLite) AddGroupMember(ctx context.Context, chatID int64, userID uuid.UUID) error {
+	query := `INSERT OR IGNORE INTO group_members (chat_id, id_user) VALUES ($1, $2)`
+	_, err := s.conn.Exec(query, chatID, userID)
+	return err
+}
+
+func (s *SQLite) IsGroupMember(ctx context.Context, chatID int64, userID uuid.UUID) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM group_members WHERE chat_id = $1 AND id_user = $2`
+	if err := s.conn.QueryRow(query, chatID, userID).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ClaimLegacyBillings moves the billings created before groups existed, and
+// the users associated with them, to the group
+func (s *SQLite) ClaimLegacyBillings(ctx context.Context, chatID int64) (claimed int64, err error) {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	query := `INSERT OR IGNORE INTO group_members (chat_id, id_user) SELECT $1, id_user FROM group_members WHERE chat_id = 0`
+	if _, err = tx.Exec(query, chatID); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(`UPDATE billings SET chat_id = $1 WHERE chat_id = 0`, chatID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+func (s *SQLite) insertBillingChange(e execer, change *types.BillingChange) error {
+	query := `INSERT INTO billing_history (id, id_billing, field, old_value, new_value, id_user, created_at)
+					VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := e.Exec(query,
+		change.ID,
+		change.BillingID,
+		change.Field,
+		change.OldValue,
+		change.NewValue,
+		nullUUID(change.ActorID),
+		change.CreatedAt,
+	)
+	return err
+}
+
+func (s *SQLite) ListBillingHistory(ctx context.Context, billingID uuid.UUID) ([]types.BillingChange, error) {
+	query := `SELECT id, id_billing, field, old_value, new_value, id_user, created_at
+					FROM billing_history WHERE id_billing = $1 ORDER BY created_at`
+	rows, err := s.conn.Query(query, billingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []types.BillingChange{}
+	for rows.Next() {
+		change := types.BillingChange{}
+		err := rows.Scan(
+			&change.ID,
+			&change.BillingID,
+			&change.Field,
+			&change.OldValue,
+			&change.NewValue,
+			&change.ActorID,
+			&change.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+
+	"misaki/types"
+)
+
+// CreateBillings inserts the billings and their payment associations in a
+// single transaction, nothing is created when any insert fails
+func (s *SQLite) CreateBillings(ctx context.Context, billings []*types.Billing) (err error) {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	for _, billing := range billings {
+		if err = s.insertBilling(tx, billing); err != nil {
+			return err
+		}
+
+		for i := range billing.Payments {
+			billing.Payments[i].BillingID = billing.ID
+			if err = s.insertPayment(tx, &billing.Payments[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
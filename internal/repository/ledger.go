@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+func (s *SQLite) CreatePaymentEntry(ctx context.Context, entry *types.PaymentEntry) error {
+	return s.insertPaymentEntry(s.conn, entry)
+}
+
+// CreatePaymentEntries inserts the entries in a single transaction, nothing
+// is recorded when any insert fails
+func (s *SQLite) CreatePaymentEntries(ctx context.Context, entries []*types.PaymentEntry) (err error) {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	for _, entry := range entries {
+		if err = s.insertPaymentEntry(tx, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLite) insertPaymentEntry(e execer, entry *types.PaymentEntry) error {
+	query := `INSERT INTO payment_entries (id, id_billing, id_user, amount, fee, note, recorded_by, created_at)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := e.Exec(query,
+		entry.ID,
+		entry.BillingID,
+		entry.UserID,
+		entry.Amount,
+		entry.Fee,
+		entry.Note,
+		nullUUID(entry.RecordedBy),
+		entry.CreatedAt,
+	)
+	return err
+}
+
+func (s *SQLite) ListPaymentEntries(ctx context.Context, billingID uuid.UUID) ([]types.PaymentEntry, error) {
+	query := `SELECT id, id_billing, id_user, amount, fee, note, recorded_by, created_at
+					FROM payment_entries
+					WHERE id_billing = $1
+					ORDER BY created_at`
+	rows, err := s.conn.Query(query, billingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []types.PaymentEntry{}
+	for rows.Next() {
+		entry := types.PaymentEntry{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.BillingID,
+			&entry.UserID,
+			&entry.Amount,
+			&entry.Fee,
+			&entry.Note,
+			&entry.RecordedBy,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+func (s *SQLite) CreateBillingNote(ctx context.Context, note *types.BillingNote) error {
+	query := `INSERT INTO billing_notes (id, id_billing, id_user, text, file_id, file_type, created_at)
+					VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := s.conn.Exec(query,
+		note.ID,
+		note.BillingID,
+		nullUUID(note.AuthorID),
+		note.Text,
+		note.FileID,
+		note.FileType,
+		note.CreatedAt,
+	)
+	return err
+}
+
+// ListBillingNotes returns the latest notes of the billing, oldest first
+func (s *SQLite) ListBillingNotes(ctx context.Context, billingID uuid.UUID, limit int) ([]types.BillingNote, error) {
+	query := `SELECT n.id, n.id_billing, n.id_user, n.text, n.file_id, n.file_type, n.created_at, u.telegram_id, u.telegram_name
+					FROM billing_notes AS n
+					LEFT JOIN users AS u
+					ON n.id_user = u.id
+					WHERE id_billing = $1
+					ORDER BY n.created_at DESC
+					LIMIT $2`
+	rows, err := s.conn.Query(query, billingID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []types.BillingNote{}
+	for rows.Next() {
+		note := types.BillingNote{}
+		var telegramID sql.NullInt64
+		var telegramName sql.NullString
+		err := rows.Scan(
+			&note.ID,
+			&note.BillingID,
+			&note.AuthorID,
+			&note.Text,
+			&note.FileID,
+			&note.FileType,
+			&note.CreatedAt,
+			&telegramID,
+			&telegramName,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if note.AuthorID != uuid.Nil {
+			note.Author = &types.User{
+				UserID:       note.AuthorID,
+				TelegramID:   telegramID.Int64,
+				TelegramName: telegramName.String,
+			}
+		}
+		notes = append(notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(notes)
+	return notes, nil
+}
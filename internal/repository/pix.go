@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"misaki/types"
+)
+
+func (s *SQLite) UpdateUserPix(ctx context.Context, user *types.User) error {
+	query := `UPDATE users SET pix_key = $1, pix_city = $2 WHERE id = $3 OR telegram_id = $4`
+	_, err := s.conn.Exec(query, user.PixKey, user.PixCity, user.UserID, user.TelegramID)
+	return err
+}
+
+// GetPixReceiver returns the oldest admin with a PIX key registered
+func (s *SQLite) GetPixReceiver(ctx context.Context) (*types.User, error) {
+	user := &types.User{}
+	query := `SELECT id, telegram_id, telegram_name, admin, reminders, created_at, pix_key, pix_city
+					FROM users
+					WHERE admin = 1 AND pix_key <> '' AND ` + notDeleted + `
+					ORDER BY created_at
+					LIMIT 1`
+	err := s.conn.QueryRow(query).Scan(
+		&user.UserID,
+		&user.TelegramID,
+		&user.TelegramName,
+		&user.Admin,
+		&user.Reminders,
+		&user.CreatedAt,
+		&user.PixKey,
+		&user.PixCity,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
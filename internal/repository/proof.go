@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+const proofColumns = `id, id_billing, id_user, amount, note, file_id, file_type, status, reason, reviewed_by, reviewed_at, created_at`
+
+func scanPaymentProof(row scanner, proof *types.PaymentProof) error {
+	return row.Scan(
+		&proof.ID,
+		&proof.BillingID,
+		&proof.UserID,
+		&proof.Amount,
+		&proof.Note,
+		&proof.FileID,
+		&proof.FileType,
+		&proof.Status,
+		&proof.Reason,
+		&proof.ReviewedBy,
+		&proof.ReviewedAt,
+		&proof.CreatedAt,
+	)
+}
+
+func (s *SQLite) CreatePaymentProof(ctx context.Context, proof *types.PaymentProof) error {
+	query := `INSERT INTO payment_proofs (id, id_billing, id_user, amount, note, file_id, file_type, status, created_at)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err := s.conn.Exec(query,
+		proof.ID,
+		proof.BillingID,
+		proof.UserID,
+		proof.Amount,
+		proof.Note,
+		proof.FileID,
+		proof.FileType,
+		proof.Status,
+		proof.CreatedAt,
+	)
+	return err
+}
+
+func (s *SQLite) GetPaymentProof(ctx context.Context, proof *types.PaymentProof) (*types.PaymentProof, error) {
+	query := `SELECT ` + proofColumns + ` FROM payment_proofs WHERE id = $1`
+	if err := scanPaymentProof(s.conn.QueryRow(query, proof.ID), proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+func (s *SQLite) ListPaymentProofs(ctx context.Context, billingID uuid.UUID) ([]types.PaymentProof, error) {
+	query := `SELECT ` + proofColumns + ` FROM payment_proofs WHERE id_billing = $1 ORDER BY created_at`
+	rows, err := s.conn.Query(query, billingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	proofs := []types.PaymentProof{}
+	for rows.Next() {
+		proof := types.PaymentProof{}
+		if err := scanPaymentProof(rows, &proof); err != nil {
+			return nil, err
+		}
+		proofs = append(proofs, proof)
+	}
+
+	return proofs, rows.Err()
+}
+
+// UpdatePaymentProof saves the review of a proof still pending, sql.ErrNoRows
+// is returned when it was already reviewed
+func (s *SQLite) UpdatePaymentProof(ctx context.Context, proof *types.PaymentProof) error {
+	return s.reviewPaymentProof(s.conn, proof)
+}
+
+// ApprovePaymentProof saves the review of a proof still pending and records
+// its ledger entry in a single transaction, so a proof is never approved
+// twice nor approved without its payment
+func (s *SQLite) ApprovePaymentProof(ctx context.Context, proof *types.PaymentProof, entry *types.PaymentEntry) (err error) {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	if err = s.reviewPaymentProof(tx, proof); err != nil {
+		return err
+	}
+
+	return s.insertPaymentEntry(tx, entry)
+}
+
+func (s *SQLite) reviewPaymentProof(e execer, proof *types.PaymentProof) error {
+	query := `UPDATE payment_proofs SET status = $1, reason = $2, reviewed_by = $3, reviewed_at = $4 WHERE id = $5 AND status = $6`
+	result, err := e.Exec(query,
+		proof.Status,
+		proof.Reason,
+		nullUUID(proof.ReviewedBy),
+		proof.ReviewedAt,
+		proof.ID,
+		types.ProofPendingReview,
+	)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"misaki/types"
+)
+
+func (s *SQLite) CreateExchangeRate(ctx context.Context, rate *types.ExchangeRate) error {
+	query := `INSERT INTO exchange_rates (id, base, quote, rate, effective_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := s.conn.Exec(query, rate.ID, rate.Base, rate.Quote, rate.Rate, rate.EffectiveAt, rate.CreatedAt)
+	return err
+}
+
+// ListExchangeRates returns the rates of the pair, latest effective first.
+// Empty currencies match every currency
+func (s *SQLite) ListExchangeRates(ctx context.Context, base, quote string) ([]types.ExchangeRate, error) {
+	query := `SELECT id, base, quote, rate, effective_at, created_at FROM exchange_rates WHERE 1 = 1`
+	args := []any{}
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if base != "" {
+		query += ` AND base = ` + arg(base)
+	}
+
+	if quote != "" {
+		query += ` AND quote = ` + arg(quote)
+	}
+
+	rows, err := s.conn.Query(query+` ORDER BY effective_at DESC, created_at DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []types.ExchangeRate{}
+	for rows.Next() {
+		rate := types.ExchangeRate{}
+		if err := rows.Scan(&rate.ID, &rate.Base, &rate.Quote, &rate.Rate, &rate.EffectiveAt, &rate.CreatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
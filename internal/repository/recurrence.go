@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+func (s *SQLite) ListBillingCycles(ctx context.Context, seriesID uuid.UUID) ([]*types.Billing, error) {
+	query := `SELECT ` + billingColumns + ` FROM billings WHERE (id = $1 OR id_parent = $1) AND ` + notDeleted + ` ORDER BY cycle`
+	return s.queryBillings(query, seriesID)
+}
+
+func (s *SQLite) ListDueRecurringBillings(ctx context.Context, now time.Time) ([]*types.Billing, error) {
+	query := `SELECT ` + billingColumns + ` FROM billings WHERE recurrence != '' AND next_cycle_at <= $1 AND ` + notDeleted
+	return s.queryBillings(query, now)
+}
+
+// GetLatestBillingCycle returns the cycle with the highest number, deleted
+// cycles included so their names are not reused
+func (s *SQLite) GetLatestBillingCycle(ctx context.Context, seriesID uuid.UUID) (*types.Billing, error) {
+	billing := &types.Billing{}
+	query := `SELECT ` + billingColumns + ` FROM billings WHERE id = $1 OR id_parent = $1 ORDER BY cycle DESC LIMIT 1`
+	if err := scanBilling(s.conn.QueryRow(query, seriesID), billing); err != nil {
+		return nil, err
+	}
+
+	payments, err := s.listPayments(s.conn, billing.ID)
+	if err != nil {
+		return nil, err
+	}
+	billing.Payments = payments
+
+	return billing, nil
+}
+
+func (s *SQLite) CreateBillingCycle(ctx context.Context, series *types.Billing, cycle *types.Billing) (err error) {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	if err = s.insertBilling(tx, cycle); err != nil {
+		return err
+	}
+
+	for i := range cycle.Payments {
+		if err = s.insertPayment(tx, &cycle.Payments[i]); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE billings SET next_cycle_at = $1 WHERE id = $2`
+	_, err = tx.Exec(query, series.NextCycleAt, series.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"misaki/types"
+)
+
+func (s *SQLite) UpdateUserReminders(ctx context.Context, user *types.User) error {
+	query := `UPDATE users SET reminders = $1 WHERE id = $2 OR telegram_id = $3`
+	_, err := s.conn.Exec(query, user.Reminders, user.UserID, user.TelegramID)
+	return err
+}
+
+func (s *SQLite) ListPaymentsWithDueDate(ctx context.Context) ([]*types.Reminder, error) {
+	query := `SELECT b.id, b.name, b.due_at, bu.id_user, bu.reminded_at, u.telegram_id, u.telegram_name
+					FROM billing_user AS bu
+					INNER JOIN billings AS b
+					ON bu.id_billing = b.id
+					INNER JOIN users AS u
+					ON bu.id_user = u.id
+					WHERE u.reminders = 1 AND b.due_at > '0001-01-01 00:00:00+00:00'
+					AND b.` + notDeleted + ` AND u.` + notDeleted
+	rows, err := s.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []*types.Reminder{}
+	for rows.Next() {
+		r := &types.Reminder{}
+		err := rows.Scan(
+			&r.Billing.ID,
+			&r.Billing.Name,
+			&r.Billing.DueAt,
+			&r.Payment.UserID,
+			&r.Payment.RemindedAt,
+			&r.Payment.UserInfo.TelegramID,
+			&r.Payment.UserInfo.TelegramName,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		r.Payment.BillingID = r.Billing.ID
+		r.Payment.UserInfo.UserID = r.Payment.UserID
+		reminders = append(reminders, r)
+	}
+
+	return reminders, rows.Err()
+}
+
+func (s *SQLite) MarkReminded(ctx context.Context, payment *types.Payment) error {
+	query := `UPDATE billing_user SET reminded_at = $1 WHERE id_billing = $2 AND id_user = $3`
+	_, err := s.conn.Exec(query, payment.RemindedAt, payment.BillingID, payment.UserID)
+	return err
+}
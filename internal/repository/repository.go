@@ -2,28 +2,130 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"misaki/types"
+
+	"github.com/google/uuid"
 )
 
 type Repository interface {
 	repositoryUser
 	repositoryBilling
+	repositoryRecurrence
+	repositoryReminder
+	repositoryLedger
+	repositoryPix
+	repositoryProof
+	repositoryHistory
+	repositoryArchive
+	repositoryGroup
+	repositoryTemplate
+	repositoryNote
+	repositoryAudit
+	repositoryBudget
+	repositoryRate
+	repositoryConversation
 }
 
 type repositoryUser interface {
 	CreateUser(ctx context.Context, user *types.User) error
 	GetUser(ctx context.Context, user *types.User) (*types.User, error)
 	DeleteUser(ctx context.Context, user *types.User) error
+	UpdateUserReminders(ctx context.Context, user *types.User) error
+	ListAdmins(ctx context.Context) ([]*types.User, error)
 }
 
 type repositoryBilling interface {
 	GetBilling(ctx context.Context, billing *types.Billing) (*types.Billing, error)
-	ListBillings(ctx context.Context) ([]*types.Billing, error)
-	CreateBilling(ctx context.Context, billing *types.Billing) error
+	ListBillings(ctx context.Context, filter types.BillingFilter) ([]*types.Billing, error)
+	CreateBillings(ctx context.Context, billings []*types.Billing) error
+	UpdateBilling(ctx context.Context, billing *types.Billing, changes []types.BillingChange) error
 	DeleteBilling(ctx context.Context, billing *types.Billing) error
-	AssociatePayment(ctx context.Context, payment *types.Payment) error
-	DisassociatePayment(ctx context.Context, payment *types.Payment) error
-	ChangePaymentStatus(ctx context.Context, payment *types.Payment) error
+	SavePaymentAssociations(ctx context.Context, associate, update []*types.Payment) error
+	DisassociatePayments(ctx context.Context, payments []*types.Payment) error
 	GetPaymentAssociation(ctx context.Context, payment *types.Payment) (*types.Payment, error)
 }
+
+type repositoryLedger interface {
+	CreatePaymentEntry(ctx context.Context, entry *types.PaymentEntry) error
+	CreatePaymentEntries(ctx context.Context, entries []*types.PaymentEntry) error
+	ListPaymentEntries(ctx context.Context, billingID uuid.UUID) ([]types.PaymentEntry, error)
+}
+
+type repositoryProof interface {
+	CreatePaymentProof(ctx context.Context, proof *types.PaymentProof) error
+	GetPaymentProof(ctx context.Context, proof *types.PaymentProof) (*types.PaymentProof, error)
+	ListPaymentProofs(ctx context.Context, billingID uuid.UUID) ([]types.PaymentProof, error)
+	UpdatePaymentProof(ctx context.Context, proof *types.PaymentProof) error
+	ApprovePaymentProof(ctx context.Context, proof *types.PaymentProof, entry *types.PaymentEntry) error
+}
+
+type repositoryHistory interface {
+	ListBillingHistory(ctx context.Context, billingID uuid.UUID) ([]types.BillingChange, error)
+}
+
+type repositoryNote interface {
+	CreateBillingNote(ctx context.Context, note *types.BillingNote) error
+	ListBillingNotes(ctx context.Context, billingID uuid.UUID, limit int) ([]types.BillingNote, error)
+}
+
+type repositoryAudit interface {
+	CreateAuditEntry(ctx context.Context, entry *types.AuditEntry) error
+	ListAuditEntries(ctx context.Context, filter types.AuditFilter) ([]types.AuditEntry, error)
+}
+
+type repositoryBudget interface {
+	CreateBudget(ctx context.Context, budget *types.Budget) error
+	ListBudgets(ctx context.Context, chatID int64) ([]*types.Budget, error)
+	UpdateBudgetAlert(ctx context.Context, budget *types.Budget) error
+}
+
+type repositoryRate interface {
+	CreateExchangeRate(ctx context.Context, rate *types.ExchangeRate) error
+	ListExchangeRates(ctx context.Context, base, quote string) ([]types.ExchangeRate, error)
+}
+
+type repositoryConversation interface {
+	SaveConversation(ctx context.Context, conversation *types.Conversation) error
+	DeleteConversation(ctx context.Context, chatID, telegramID int64) error
+	ListConversations(ctx context.Context) ([]*types.Conversation, error)
+}
+
+type repositoryArchive interface {
+	RestoreBilling(ctx context.Context, billing *types.Billing) error
+	RestoreUser(ctx context.Context, user *types.User) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, int64, error)
+}
+
+type repositoryGroup interface {
+	SaveGroup(ctx context.Context, group *types.Group) error
+	GetGroup(ctx context.Context, chatID int64) (*types.Group, error)
+	UpdateGroupCurrency(ctx context.Context, group *types.Group) error
+	AddGroupMember(ctx context.Context, chatID int64, userID uuid.UUID) error
+	IsGroupMember(ctx context.Context, chatID int64, userID uuid.UUID) (bool, error)
+	ClaimLegacyBillings(ctx context.Context, chatID int64) (int64, error)
+}
+
+type repositoryTemplate interface {
+	CreateTemplate(ctx context.Context, template *types.Template) error
+	GetTemplate(ctx context.Context, template *types.Template) (*types.Template, error)
+	ListTemplates(ctx context.Context, chatID int64) ([]*types.Template, error)
+}
+
+type repositoryPix interface {
+	UpdateUserPix(ctx context.Context, user *types.User) error
+	GetPixReceiver(ctx context.Context) (*types.User, error)
+}
+
+type repositoryRecurrence interface {
+	ListBillingCycles(ctx context.Context, seriesID uuid.UUID) ([]*types.Billing, error)
+	ListDueRecurringBillings(ctx context.Context, now time.Time) ([]*types.Billing, error)
+	GetLatestBillingCycle(ctx context.Context, seriesID uuid.UUID) (*types.Billing, error)
+	CreateBillingCycle(ctx context.Context, series *types.Billing, cycle *types.Billing) error
+}
+
+type repositoryReminder interface {
+	ListPaymentsWithDueDate(ctx context.Context) ([]*types.Reminder, error)
+	MarkReminded(ctx context.Context, payment *types.Payment) error
+}
@@ -5,10 +5,14 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"misaki/config"
 	"misaki/types"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -51,9 +55,9 @@ func NewSQLite(config *config.Config, logger *zap.Logger) (Repository, error) {
 	return repo, nil
 }
 
-func (s *SQLite) migrate(filepath string) error {
+func (s *SQLite) migrate(schemaPath string) error {
 	// Read the schema file
-	schema, err := os.ReadFile(filepath)
+	schema, err := os.ReadFile(schemaPath)
 	if err != nil {
 		return err
 	}
@@ -64,93 +68,256 @@ func (s *SQLite) migrate(filepath string) error {
 		return err
 	}
 
+	return s.applyMigrations(filepath.Dir(schemaPath))
+}
+
+// applyMigrations executes the numbered migration files (e.g. 001_name.sql)
+// found next to the schema file, in order, recording each applied version so
+// it only runs once per database.
+func (s *SQLite) applyMigrations(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "[0-9]*.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	ctx := context.Background()
+	conn, err := s.conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	// Foreign keys must be disabled while migrations rebuild tables,
+	// otherwise dropping a table would cascade into its dependents
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+
+	for _, file := range files {
+		version := filepath.Base(file)
+
+		var applied int
+		query := `SELECT COUNT(*) FROM schema_migrations WHERE version = $1`
+		if err := conn.QueryRowContext(ctx, query, version).Scan(&applied); err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		if err := s.applyMigration(ctx, conn, version, string(content)); err != nil {
+			return fmt.Errorf("migration %s: %w", version, err)
+		}
+		s.logger.Info("Migration applied", zap.String("version", version))
+	}
+
 	return nil
 }
 
+func (s *SQLite) applyMigration(ctx context.Context, conn *sql.Conn, version, content string) (err error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	if _, err = tx.Exec(content); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, version)
+	return err
+}
+
 func (s *SQLite) CreateUser(ctx context.Context, user *types.User) error {
-	query := `INSERT INTO users (id, telegram_id, telegram_name, admin, created_at) VALUES ($1, $2, $3, $4, $5)`
+	query := `INSERT INTO users (id, telegram_id, telegram_name, admin, reminders, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
 	_, err := s.conn.Exec(query,
 		user.UserID,
 		user.TelegramID,
 		user.TelegramName,
 		user.Admin,
+		user.Reminders,
 		user.CreatedAt,
 	)
 	return err
 }
 
 func (s *SQLite) GetUser(ctx context.Context, user *types.User) (*types.User, error) {
-	query := `SELECT id, telegram_id, telegram_name, admin, created_at FROM users WHERE id = $1 OR telegram_id = $2`
+	query := `SELECT id, telegram_id, telegram_name, admin, reminders, created_at, pix_key, pix_city, deleted_at FROM users WHERE id = $1 OR telegram_id = $2`
+	var deletedAt sql.NullTime
 	err := s.conn.QueryRow(query, user.UserID, user.TelegramID).Scan(
 		&user.UserID,
 		&user.TelegramID,
 		&user.TelegramName,
 		&user.Admin,
+		&user.Reminders,
 		&user.CreatedAt,
+		&user.PixKey,
+		&user.PixCity,
+		&deletedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	user.DeletedAt = deletedAt.Time
 	return user, nil
 }
 
-func (s *SQLite) DeleteUser(ctx context.Context, user *types.User) error {
-	tx, err := s.conn.Begin()
+func (s *SQLite) ListAdmins(ctx context.Context) ([]*types.User, error) {
+	query := `SELECT id, telegram_id, telegram_name, admin, reminders, created_at, pix_key, pix_city FROM users WHERE admin = 1 AND ` + notDeleted + ` ORDER BY created_at`
+	rows, err := s.conn.Query(query)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer func() {
+	defer rows.Close()
+
+	users := []*types.User{}
+	for rows.Next() {
+		user := &types.User{}
+		err := rows.Scan(
+			&user.UserID,
+			&user.TelegramID,
+			&user.TelegramName,
+			&user.Admin,
+			&user.Reminders,
+			&user.CreatedAt,
+			&user.PixKey,
+			&user.PixCity,
+		)
 		if err != nil {
-			_ = tx.Rollback()
-			return
+			return nil, err
 		}
-		err = tx.Commit()
-	}()
-
-	query := `DELETE FROM users WHERE id = $1 OR telegram_id = $2`
-	_, err = tx.Exec(query, user.UserID, user.TelegramID)
-	if err != nil {
-		return err
+		users = append(users, user)
 	}
 
-	return nil
+	return users, rows.Err()
 }
 
-func (s *SQLite) GetBilling(ctx context.Context, billing *types.Billing) (*types.Billing, error) {
-	query := `SELECT id, name, value, created_at FROM billings WHERE id = $1 or name = $2`
-	err := s.conn.QueryRow(query, billing.ID, billing.Name).Scan(
+// DeleteUser soft deletes the user, its associations are kept until purged
+func (s *SQLite) DeleteUser(ctx context.Context, user *types.User) error {
+	query := `UPDATE users SET deleted_at = $1 WHERE id = $2 OR telegram_id = $3`
+	_, err := s.conn.Exec(query, user.DeletedAt, user.UserID, user.TelegramID)
+	return err
+}
+
+const billingColumns = `id, name, amount, currency, created_at, due_at, id_payer, id_parent, cycle, recurrence, recurrence_day, period_start, next_cycle_at,
+	COALESCE(category, ''), COALESCE((SELECT group_concat(tag) FROM billing_tags WHERE id_billing = billings.id), ''), deleted_at, chat_id, installments,
+	late_fee_flat, late_fee_rate, late_fee_cap`
+
+// notDeleted matches the users and billings that were not soft deleted
+const notDeleted = `deleted_at IS NULL`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanBilling(row scanner, billing *types.Billing) error {
+	var tags string
+	var deletedAt sql.NullTime
+	err := row.Scan(
 		&billing.ID,
 		&billing.Name,
 		&billing.Value,
+		&billing.Currency,
 		&billing.CreatedAt,
+		&billing.DueAt,
+		&billing.PayerID,
+		&billing.ParentID,
+		&billing.Cycle,
+		&billing.Recurrence,
+		&billing.RecurrenceDay,
+		&billing.PeriodStart,
+		&billing.NextCycleAt,
+		&billing.Category,
+		&tags,
+		&deletedAt,
+		&billing.ChatID,
+		&billing.Installments,
+		&billing.LateFee.Flat,
+		&billing.LateFee.DailyRate,
+		&billing.LateFee.Cap,
 	)
+	if err != nil {
+		return err
+	}
+	billing.DeletedAt = deletedAt.Time
+
+	billing.Tags = []string{}
+	if tags != "" {
+		billing.Tags = strings.Split(tags, ",")
+		sort.Strings(billing.Tags)
+	}
+	return nil
+}
+
+func (s *SQLite) GetBilling(ctx context.Context, billing *types.Billing) (*types.Billing, error) {
+	// Identifiers are unique but names are unique only in the group
+	query := `SELECT ` + billingColumns + ` FROM billings WHERE id = $1 OR (name = $2 AND chat_id = $3)`
+	err := scanBilling(s.conn.QueryRow(query, billing.ID, billing.Name, billing.ChatID), billing)
 	if err != nil {
 		return nil, err
 	}
 
 	// Query associated users
-	billing.Payments = []types.Payment{}
-	query = `SELECT bu.id_billing, bu.id_user, bu.paid, bu.paid_at, telegram_id, telegram_name
+	billing.Payments, err = s.listPayments(s.conn, billing.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return billing, nil
+}
+
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func (s *SQLite) listPayments(q querier, billingID uuid.UUID) ([]types.Payment, error) {
+	query := `SELECT bu.id_billing, bu.id_user, bu.reminded_at, bu.share_type, bu.share_value, bu.share_amount, telegram_id, telegram_name, reminders
 					FROM billing_user AS bu 
 					INNER JOIN users AS u 
 					ON bu.id_user = u.id 
 					WHERE id_billing = $1`
-	rows, err := s.conn.Query(query, billing.ID)
+	rows, err := q.Query(query, billingID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	payments := []types.Payment{}
 	for rows.Next() {
 		payment := types.Payment{}
 		user := types.User{}
 		err := rows.Scan(
 			&payment.BillingID,
 			&payment.UserID,
-			&payment.Paid,
-			&payment.PaidAt,
+			&payment.RemindedAt,
+			&payment.Share.Type,
+			&payment.Share.Value,
+			&payment.Share.Amount,
 			&user.TelegramID,
 			&user.TelegramName,
+			&user.Reminders,
 		)
 		if err != nil {
 			return nil, err
@@ -159,15 +326,46 @@ func (s *SQLite) GetBilling(ctx context.Context, billing *types.Billing) (*types
 		user.UserID = payment.UserID
 		payment.UserInfo = user
 
-		billing.Payments = append(billing.Payments, payment)
+		payments = append(payments, payment)
 	}
 
-	return billing, nil
+	return payments, rows.Err()
 }
 
-func (s *SQLite) ListBillings(ctx context.Context) ([]*types.Billing, error) {
-	query := `SELECT id, name, value, created_at FROM billings`
-	rows, err := s.conn.Query(query)
+func (s *SQLite) ListBillings(ctx context.Context, filter types.BillingFilter) ([]*types.Billing, error) {
+	query := `SELECT ` + billingColumns + ` FROM billings WHERE ` + notDeleted
+	args := []any{}
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.ChatID != 0 {
+		query += ` AND chat_id = ` + arg(filter.ChatID)
+	}
+
+	if filter.Category != "" {
+		query += ` AND category = ` + arg(filter.Category)
+	}
+
+	if filter.Tag != "" {
+		query += ` AND EXISTS (SELECT 1 FROM billing_tags WHERE id_billing = billings.id AND tag = ` + arg(filter.Tag) + `)`
+	}
+
+	if filter.UserID != uuid.Nil {
+		query += ` AND EXISTS (SELECT 1 FROM billing_user WHERE id_billing = billings.id AND id_user = ` + arg(filter.UserID) + `)`
+	}
+
+	if filter.Unpaid {
+		query += ` AND EXISTS (SELECT 1 FROM billing_user WHERE id_billing = billings.id)
+					AND (SELECT COALESCE(SUM(amount), 0) FROM payment_entries WHERE id_billing = billings.id) < billings.amount`
+	}
+
+	return s.queryBillings(query+` ORDER BY created_at`, args...)
+}
+
+func (s *SQLite) queryBillings(query string, args ...any) ([]*types.Billing, error) {
+	rows, err := s.conn.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -178,33 +376,87 @@ func (s *SQLite) ListBillings(ctx context.Context) ([]*types.Billing, error) {
 
 	for rows.Next() {
 		b := &types.Billing{}
-		err := rows.Scan(
-			&b.ID,
-			&b.Name,
-			&b.Value,
-			&b.CreatedAt,
-		)
-		if err != nil {
+		if err := scanBilling(rows, b); err != nil {
 			return nil, err
 		}
 
 		billings = append(billings, b)
 	}
-	return billings, nil
+	return billings, rows.Err()
 }
 
-func (s *SQLite) CreateBilling(ctx context.Context, billing *types.Billing) error {
-	query := `INSERT INTO billings (id, name, value, created_at) VALUES ($1, $2, $3, $4)`
-	_, err := s.conn.Exec(query,
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func (s *SQLite) insertBilling(e execer, billing *types.Billing) error {
+	// Groups are created with their first billing when no user joined yet
+	_, err := e.Exec(`INSERT OR IGNORE INTO groups (chat_id) VALUES ($1)`, billing.ChatID)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO billings (id, name, amount, currency, created_at, due_at, id_payer, id_parent, cycle, recurrence, recurrence_day, period_start, next_cycle_at, chat_id, installments, late_fee_flat, late_fee_rate, late_fee_cap)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
+	_, err = e.Exec(query,
 		billing.ID,
 		billing.Name,
 		billing.Value,
+		billing.Currency,
 		billing.CreatedAt,
+		billing.DueAt,
+		nullUUID(billing.PayerID),
+		nullUUID(billing.ParentID),
+		billing.Cycle,
+		billing.Recurrence,
+		billing.RecurrenceDay,
+		billing.PeriodStart,
+		billing.NextCycleAt,
+		billing.ChatID,
+		billing.Installments,
+		billing.LateFee.Flat,
+		billing.LateFee.DailyRate,
+		billing.LateFee.Cap,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	return s.saveBillingLabels(e, billing)
 }
 
-func (s *SQLite) DeleteBilling(ctx context.Context, billing *types.Billing) error {
+// saveBillingLabels creates the category of the billing when missing and
+// replaces its tags
+func (s *SQLite) saveBillingLabels(e execer, billing *types.Billing) error {
+	if billing.Category != "" {
+		query := `INSERT OR IGNORE INTO categories (name) VALUES ($1)`
+		if _, err := e.Exec(query, billing.Category); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE billings SET category = $1 WHERE id = $2`
+	if _, err := e.Exec(query, nullString(billing.Category), billing.ID); err != nil {
+		return err
+	}
+
+	query = `DELETE FROM billing_tags WHERE id_billing = $1`
+	if _, err := e.Exec(query, billing.ID); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO billing_tags (id_billing, tag) VALUES ($1, $2)`
+	for _, tag := range billing.Tags {
+		if _, err := e.Exec(query, billing.ID, tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateBilling saves the billing and records its changes in the history
+func (s *SQLite) UpdateBilling(ctx context.Context, billing *types.Billing, changes []types.BillingChange) (err error) {
 	tx, err := s.conn.Begin()
 	if err != nil {
 		return err
@@ -217,27 +469,58 @@ func (s *SQLite) DeleteBilling(ctx context.Context, billing *types.Billing) erro
 		err = tx.Commit()
 	}()
 
-	query := `DELETE FROM billings WHERE id = $1 OR name = $2`
-	_, err = tx.Exec(query, billing.ID, billing.Name)
+	query := `UPDATE billings SET name = $1, amount = $2, due_at = $3, id_payer = $4, late_fee_flat = $5, late_fee_rate = $6, late_fee_cap = $7 WHERE id = $8`
+	_, err = tx.Exec(query,
+		billing.Name,
+		billing.Value,
+		billing.DueAt,
+		nullUUID(billing.PayerID),
+		billing.LateFee.Flat,
+		billing.LateFee.DailyRate,
+		billing.LateFee.Cap,
+		billing.ID,
+	)
 	if err != nil {
 		return err
 	}
 
+	if err = s.saveBillingLabels(tx, billing); err != nil {
+		return err
+	}
+
+	for i := range changes {
+		if err = s.insertBillingChange(tx, &changes[i]); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (s *SQLite) AssociatePayment(ctx context.Context, payment *types.Payment) error {
-	query := `INSERT INTO billing_user (id_billing, id_user, paid, paid_at) VALUES ($1, $2, $3, $4)`
-	_, err := s.conn.Exec(query,
+// DeleteBilling soft deletes the billing, deleting the first billing of a
+// recurring series deletes its cycles too
+func (s *SQLite) DeleteBilling(ctx context.Context, billing *types.Billing) error {
+	query := `UPDATE billings SET deleted_at = $1 WHERE (id = $2 OR id_parent = $2) AND ` + notDeleted
+	_, err := s.conn.Exec(query, billing.DeletedAt, billing.ID)
+	return err
+}
+
+func (s *SQLite) insertPayment(e execer, payment *types.Payment) error {
+	query := `INSERT INTO billing_user (id_billing, id_user, share_type, share_value, share_amount) VALUES ($1, $2, $3, $4, $5)`
+	_, err := e.Exec(query,
 		payment.BillingID,
 		payment.UserID,
-		payment.Paid,
-		payment.PaidAt,
+		payment.Share.Type,
+		payment.Share.Value,
+		payment.Share.Amount,
 	)
 	return err
 }
 
-func (s *SQLite) DisassociatePayment(ctx context.Context, payment *types.Payment) error {
+// SavePaymentAssociations inserts the new associations and updates the share
+// of the existing ones in a single transaction, nothing is changed when any
+// write fails
+func (s *SQLite) SavePaymentAssociations(ctx context.Context, associate, update []*types.Payment) (err error) {
 	tx, err := s.conn.Begin()
 	if err != nil {
 		return err
@@ -250,39 +533,81 @@ func (s *SQLite) DisassociatePayment(ctx context.Context, payment *types.Payment
 		err = tx.Commit()
 	}()
 
-	query := `DELETE FROM billing_user WHERE id_billing = $1 AND id_user = $2`
-	_, err = tx.Exec(query,
-		payment.BillingID,
-		payment.UserID,
-	)
-	if err != nil {
-		return err
+	for _, payment := range associate {
+		if err = s.insertPayment(tx, payment); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE billing_user SET share_type = $1, share_value = $2, share_amount = $3 WHERE id_billing = $4 AND id_user = $5`
+	for _, payment := range update {
+		_, err = tx.Exec(query,
+			payment.Share.Type,
+			payment.Share.Value,
+			payment.Share.Amount,
+			payment.BillingID,
+			payment.UserID,
+		)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (s *SQLite) ChangePaymentStatus(ctx context.Context, payment *types.Payment) error {
-	query := `UPDATE billing_user SET paid = $1, paid_at = $2 WHERE id_billing = $3 AND id_user = $4`
-	_, err := s.conn.Exec(query,
-		payment.Paid,
-		payment.PaidAt,
-		payment.BillingID,
-		payment.UserID,
-	)
-	return err
+// DisassociatePayments removes the associations in a single transaction. The
+// ledger entries of the associations are deleted with them, the caller checks
+// they were reverted so nothing paid is lost
+func (s *SQLite) DisassociatePayments(ctx context.Context, payments []*types.Payment) (err error) {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	for _, payment := range payments {
+		query := `DELETE FROM payment_entries WHERE id_billing = $1 AND id_user = $2`
+		if _, err = tx.Exec(query, payment.BillingID, payment.UserID); err != nil {
+			return err
+		}
+
+		query = `DELETE FROM billing_user WHERE id_billing = $1 AND id_user = $2`
+		if _, err = tx.Exec(query, payment.BillingID, payment.UserID); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (s *SQLite) GetPaymentAssociation(ctx context.Context, payment *types.Payment) (*types.Payment, error) {
-	query := `SELECT id_billing, id_user, paid, paid_at FROM billing_user WHERE id_billing = $1`
+	query := `SELECT id_billing, id_user, share_type, share_value, share_amount FROM billing_user WHERE id_billing = $1 AND id_user = $2`
 	err := s.conn.QueryRow(query, payment.BillingID, payment.UserID).Scan(
 		&payment.BillingID,
 		&payment.UserID,
-		&payment.Paid,
-		&payment.PaidAt,
+		&payment.Share.Type,
+		&payment.Share.Value,
+		&payment.Share.Amount,
 	)
 	if err != nil {
 		return nil, err
 	}
 	return payment, nil
 }
+
+// nullString stores empty strings as NULL, used by optional references
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// nullUUID stores empty identifiers as NULL instead of the zero UUID
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
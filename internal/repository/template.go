@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// CreateTemplate inserts the template and its participants in a single
+// transaction
+func (s *SQLite) CreateTemplate(ctx context.Context, template *types.Template) (err error) {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	query := `INSERT INTO templates (id, chat_id, name, amount, currency, category, id_payer, created_at)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err = tx.Exec(query,
+		template.ID,
+		template.ChatID,
+		template.Name,
+		template.Value,
+		template.Currency,
+		template.Category,
+		nullUUID(template.PayerID),
+		template.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range template.Users {
+		query := `INSERT INTO template_users (id_template, id_user, share_type, share_value, share_amount) VALUES ($1, $2, $3, $4, $5)`
+		_, err = tx.Exec(query,
+			template.ID,
+			user.UserID,
+			user.Share.Type,
+			user.Share.Value,
+			user.Share.Amount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLite) GetTemplate(ctx context.Context, template *types.Template) (*types.Template, error) {
+	query := `SELECT id, chat_id, name, amount, currency, category, id_payer, created_at
+					FROM templates WHERE id = $1 OR (name = $2 AND chat_id = $3)`
+	err := scanTemplate(s.conn.QueryRow(query, template.ID, template.Name, template.ChatID), template)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Users, err = s.listTemplateUsers(template.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (s *SQLite) ListTemplates(ctx context.Context, chatID int64) ([]*types.Template, error) {
+	query := `SELECT id, chat_id, name, amount, currency, category, id_payer, created_at
+					FROM templates WHERE chat_id = $1 ORDER BY name`
+	rows, err := s.conn.Query(query, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []*types.Template{}
+	for rows.Next() {
+		template := &types.Template{}
+		if err := scanTemplate(rows, template); err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, template := range templates {
+		template.Users, err = s.listTemplateUsers(template.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return templates, nil
+}
+
+func scanTemplate(row scanner, template *types.Template) error {
+	return row.Scan(
+		&template.ID,
+		&template.ChatID,
+		&template.Name,
+		&template.Value,
+		&template.Currency,
+		&template.Category,
+		&template.PayerID,
+		&template.CreatedAt,
+	)
+}
+
+// listTemplateUsers returns the participants of the template, deleted users
+// are skipped
+func (s *SQLite) listTemplateUsers(templateID uuid.UUID) ([]types.TemplateUser, error) {
+	query := `SELECT tu.id_user, tu.share_type, tu.share_value, tu.share_amount, telegram_id, telegram_name
+					FROM template_users AS tu
+					INNER JOIN users AS u
+					ON tu.id_user = u.id
+					WHERE id_template = $1 AND u.` + notDeleted
+	rows, err := s.conn.Query(query, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []types.TemplateUser{}
+	for rows.Next() {
+		user := types.TemplateUser{}
+		err := rows.Scan(
+			&user.UserID,
+			&user.Share.Type,
+			&user.Share.Value,
+			&user.Share.Amount,
+			&user.UserInfo.TelegramID,
+			&user.UserInfo.TelegramName,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		user.UserInfo.UserID = user.UserID
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// DefaultPurgeRetention is how long deleted billings and users are kept when
+// no retention period is informed
+const DefaultPurgeRetention = 30 * 24 * time.Hour
+
+// RestoreBilling restores a deleted billing and the cycles deleted with it
+func (s *Service) RestoreBilling(ctx context.Context, billing *types.Billing) (_ *types.Billing, err error) {
+	audit := s.startAudit(ctx, "billing_restore")
+	audit.billing(billing)
+	defer s.finishAudit(ctx, audit, &err)
+
+	if billing.ID == uuid.Nil && billing.Name == "" {
+		return nil, fmt.Errorf("missing billing id")
+	}
+
+	chatID := billing.ChatID
+	billing, err = s.repository.GetBilling(ctx, billing)
+	if err != nil {
+		return nil, err
+	}
+
+	if chatID != 0 && billing.ChatID != chatID {
+		return nil, sql.ErrNoRows
+	}
+
+	audit.billing(billing)
+
+	if billing.DeletedAt.IsZero() {
+		return nil, fmt.Errorf("billing %s is not deleted", billing.Name)
+	}
+
+	if err := s.repository.RestoreBilling(ctx, billing); err != nil {
+		return nil, err
+	}
+
+	return s.GetBilling(ctx, &types.Billing{ID: billing.ID})
+}
+
+// RestoreUser restores a deleted user
+func (s *Service) RestoreUser(ctx context.Context, user *types.User) (_ *types.User, err error) {
+	audit := s.startAudit(ctx, "user_restore")
+	audit.user(user)
+	defer s.finishAudit(ctx, audit, &err)
+
+	if user.UserID == uuid.Nil && user.TelegramID <= 0 {
+		return nil, fmt.Errorf("missing identifiers to restore user")
+	}
+
+	user, err = s.repository.GetUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	audit.user(user)
+
+	if user.DeletedAt.IsZero() {
+		return nil, fmt.Errorf("user is not deleted")
+	}
+
+	if err := s.repository.RestoreUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	user.DeletedAt = time.Time{}
+	return user, nil
+}
+
+// PurgeDeleted permanently deletes the billings and users deleted longer
+// than retention ago, returning how many billings and users were purged
+func (s *Service) PurgeDeleted(ctx context.Context, retention time.Duration) (billings int64, users int64, err error) {
+	audit := s.startAudit(ctx, "purge")
+	defer s.finishAudit(ctx, audit, &err)
+
+	if retention < 0 {
+		return 0, 0, fmt.Errorf("invalid retention period: %s", retention)
+	}
+
+	billings, users, err = s.repository.PurgeDeleted(ctx, time.Now().Add(-retention))
+	audit.entry.Details = fmt.Sprintf("%d billings and %d users purged", billings, users)
+	return billings, users, err
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultAuditLimit = 20
+	maxAuditLimit     = 100
+)
+
+type auditActorKey struct{}
+
+// WithAuditActor returns a context whose mutations are audited on behalf of
+// the actor, mutations made without it are recorded as made by the bot
+func WithAuditActor(ctx context.Context, actor types.AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// auditRecord collects the targets of a mutation until it finishes, targets
+// are read when the mutation finishes so objects filled in place by it are
+// recorded with their generated fields
+type auditRecord struct {
+	entry   types.AuditEntry
+	target  *types.Billing
+	subject *types.User
+}
+
+func (s *Service) startAudit(ctx context.Context, action string) *auditRecord {
+	record := &auditRecord{entry: types.AuditEntry{Action: action}}
+
+	if actor, ok := ctx.Value(auditActorKey{}).(types.AuditActor); ok {
+		record.entry.ChatID = actor.ChatID
+		record.entry.ActorTelegramID = actor.TelegramID
+		record.entry.Command = actor.Command
+		record.entry.Arguments = actor.Arguments
+	}
+
+	return record
+}
+
+// billing sets the target billing, nil billings keep the previous target
+func (r *auditRecord) billing(billing *types.Billing) {
+	if billing != nil {
+		r.target = billing
+	}
+}
+
+// user sets the target user, nil users keep the previous target
+func (r *auditRecord) user(user *types.User) {
+	if user != nil {
+		r.subject = user
+	}
+}
+
+// fill copies the targets to the entry, entries belong to the group of the
+// billing even when the command was sent in a private chat
+func (r *auditRecord) fill() {
+	if r.target != nil {
+		r.entry.BillingID = r.target.ID
+		r.entry.BillingName = r.target.Name
+		if r.target.ChatID != 0 {
+			r.entry.ChatID = r.target.ChatID
+		}
+	}
+
+	if r.subject != nil {
+		r.entry.UserID = r.subject.UserID
+		r.entry.UserName = r.subject.TelegramName
+	}
+}
+
+// finishAudit records the result of the mutation, it is deferred with the
+// error returned by the mutation. Failing to record it is only logged since
+// the mutation was already made
+func (s *Service) finishAudit(ctx context.Context, record *auditRecord, err *error) {
+	record.fill()
+	record.entry.Result = "ok"
+	if *err != nil {
+		record.entry.Result = (*err).Error()
+	}
+
+	id, idErr := uuid.NewV7()
+	if idErr != nil {
+		s.logger.Error("error creating audit entry id", zap.Error(idErr))
+		return
+	}
+	record.entry.ID = id
+	record.entry.CreatedAt = time.Now()
+
+	if auditErr := s.repository.CreateAuditEntry(ctx, &record.entry); auditErr != nil {
+		s.logger.Error("error recording audit entry", zap.String("action", record.entry.Action), zap.Error(auditErr))
+	}
+}
+
+// ListAuditEntries returns the latest audit entries of the group, newest first
+func (s *Service) ListAuditEntries(ctx context.Context, filter types.AuditFilter) ([]types.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditLimit
+	}
+	filter.Limit = min(filter.Limit, maxAuditLimit)
+
+	return s.repository.ListAuditEntries(ctx, filter)
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// debt is an outstanding amount owed to the payer of a billing, value is the
+// amount converted to the settlement currency
+type debt struct {
+	billing  *types.Billing
+	payment  *types.Payment
+	debtor   types.User
+	creditor types.User
+	amount   int64
+	value    int64
+	currency string
+}
+
+// listDebts returns the outstanding payments of every billing of the group
+// with payer, late fees included, the payer share of the billing is not a debt
+func (s *Service) listDebts(ctx context.Context, chatID int64) ([]debt, error) {
+	billings, err := s.repository.ListBillings(ctx, types.BillingFilter{ChatID: chatID})
+	if err != nil {
+		return nil, err
+	}
+
+	debts := []debt{}
+	for _, b := range billings {
+		if b.PayerID == uuid.Nil {
+			continue
+		}
+
+		billing, err := s.GetBilling(ctx, &types.Billing{ID: b.ID})
+		if err != nil {
+			return nil, err
+		}
+
+		for i, payment := range billing.Payments {
+			if payment.UserID == billing.PayerID || payment.AmountDue <= 0 {
+				continue
+			}
+
+			value, currency := settlementAmount(billing, payment.AmountDue)
+			debts = append(debts, debt{
+				billing:  billing,
+				payment:  &billing.Payments[i],
+				debtor:   payment.UserInfo,
+				creditor: *billing.Payer,
+				amount:   payment.AmountDue,
+				value:    value,
+				currency: currency,
+			})
+		}
+	}
+
+	return debts, nil
+}
+
+// ComputeBalances nets the outstanding debts of the group into a balance
+// per user and currency, and simplifies them into the transfers needed to
+// settle everyone. Debts are converted to the settlement
+// currency of the group when there is an exchange rate
+func (s *Service) ComputeBalances(ctx context.Context, chatID int64) ([]types.Balance, []types.Transfer, error) {
+	debts, err := s.listDebts(ctx, chatID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type key struct {
+		user     uuid.UUID
+		currency string
+	}
+	net := map[key]*types.Balance{}
+	add := func(user types.User, currency string, amount int64) {
+		k := key{user.UserID, currency}
+		if _, ok := net[k]; !ok {
+			net[k] = &types.Balance{User: user, Currency: currency}
+		}
+		net[k].Amount += amount
+	}
+
+	for _, d := range debts {
+		add(d.debtor, d.currency, -d.value)
+		add(d.creditor, d.currency, d.value)
+	}
+
+	balances := []types.Balance{}
+	for _, balance := range net {
+		if balance.Amount != 0 {
+			balances = append(balances, *balance)
+		}
+	}
+	sortBalances(balances)
+
+	transfers := []types.Transfer{}
+	for _, settlement := range planSettlements(debts) {
+		transfers = append(transfers, settlement.transfer)
+	}
+
+	return balances, transfers, nil
+}
+
+// sortBalances orders balances by currency and amount, using the user ID
+// to keep the order deterministic
+func sortBalances(balances []types.Balance) {
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Currency != balances[j].Currency {
+			return balances[i].Currency < balances[j].Currency
+		}
+		if balances[i].Amount != balances[j].Amount {
+			return balances[i].Amount > balances[j].Amount
+		}
+		return balances[i].User.UserID.String() < balances[j].User.UserID.String()
+	})
+}
+
+// settlement is a simplified transfer and the value of each debt it pays off
+type settlement struct {
+	transfer types.Transfer
+	paid     map[*debt]int64
+}
+
+// planSettlements simplifies the debts into transfers, the largest debtor of
+// each currency pays the largest creditor reachable through a chain of debts,
+// so every transfer pays off existing debts and can be recorded in the ledger
+func planSettlements(debts []debt) []settlement {
+	currencies := []string{}
+	byCurrency := map[string][]*debt{}
+	for i := range debts {
+		d := &debts[i]
+		if d.value <= 0 {
+			continue
+		}
+		if _, ok := byCurrency[d.currency]; !ok {
+			currencies = append(currencies, d.currency)
+		}
+		byCurrency[d.currency] = append(byCurrency[d.currency], d)
+	}
+	sort.Strings(currencies)
+
+	settlements := []settlement{}
+	for _, currency := range currencies {
+		settlements = append(settlements, planCurrency(currency, byCurrency[currency])...)
+	}
+
+	return settlements
+}
+
+func planCurrency(currency string, debts []*debt) []settlement {
+	users := map[uuid.UUID]types.User{}
+	balance := map[uuid.UUID]int64{}
+	remaining := map[*debt]int64{}
+	edges := map[uuid.UUID][]*debt{}
+	for _, d := range debts {
+		users[d.debtor.UserID] = d.debtor
+		users[d.creditor.UserID] = d.creditor
+		balance[d.debtor.UserID] -= d.value
+		balance[d.creditor.UserID] += d.value
+		remaining[d] = d.value
+		edges[d.debtor.UserID] = append(edges[d.debtor.UserID], d)
+	}
+	for _, out := range edges {
+		sort.SliceStable(out, func(i, j int) bool {
+			return out[i].creditor.UserID.String() < out[j].creditor.UserID.String()
+		})
+	}
+
+	// largest returns the user with the largest balance of the sign, the user
+	// ID keeps the choice deterministic
+	largest := func(candidates []uuid.UUID, sign int64) uuid.UUID {
+		found := uuid.Nil
+		for _, id := range candidates {
+			if sign*balance[id] <= 0 {
+				continue
+			}
+			if found == uuid.Nil || sign*balance[id] > sign*balance[found] ||
+				(balance[id] == balance[found] && id.String() < found.String()) {
+				found = id
+			}
+		}
+		return found
+	}
+
+	ids := []uuid.UUID{}
+	for id := range users {
+		ids = append(ids, id)
+	}
+
+	settlements := []settlement{}
+	index := map[[2]uuid.UUID]int{}
+	for {
+		from := largest(ids, -1)
+		if from == uuid.Nil {
+			break
+		}
+
+		// The users reachable from the debtor always hold a positive balance
+		// as large as the debtor balance, since debts only leave them inwards
+		via := map[uuid.UUID]*debt{from: nil}
+		reached := []uuid.UUID{from}
+		for i := 0; i < len(reached); i++ {
+			for _, d := range edges[reached[i]] {
+				if _, ok := via[d.creditor.UserID]; ok || remaining[d] == 0 {
+					continue
+				}
+				via[d.creditor.UserID] = d
+				reached = append(reached, d.creditor.UserID)
+			}
+		}
+
+		to := largest(reached, 1)
+		if to == uuid.Nil {
+			break
+		}
+
+		path := []*debt{}
+		amount := min(-balance[from], balance[to])
+		for id := to; id != from; id = via[id].debtor.UserID {
+			path = append(path, via[id])
+			amount = min(amount, remaining[via[id]])
+		}
+
+		k := [2]uuid.UUID{from, to}
+		if _, ok := index[k]; !ok {
+			index[k] = len(settlements)
+			settlements = append(settlements, settlement{
+				transfer: types.Transfer{From: users[from], To: users[to], Currency: currency},
+				paid:     map[*debt]int64{},
+			})
+		}
+		current := &settlements[index[k]]
+		current.transfer.Amount += amount
+		for _, d := range path {
+			remaining[d] -= amount
+			current.paid[d] += amount
+		}
+
+		balance[from] += amount
+		balance[to] -= amount
+	}
+
+	return settlements
+}
+
+// Settle records the simplified transfers between the user and the
+// counterparty, in both directions, paying off the debts behind them in a
+// single transaction. Only the user receiving a transfer or an admin can
+// confirm it, debtors pay with /billing_pay and a receipt instead
+func (s *Service) Settle(ctx context.Context, chatID int64, user, counterparty *types.User) (_ []types.Transfer, err error) {
+	audit := s.startAudit(ctx, "settle")
+	audit.entry.ChatID = chatID
+	audit.user(user)
+	audit.entry.Details = fmt.Sprintf("with %s", counterparty.TelegramName)
+	defer s.finishAudit(ctx, audit, &err)
+
+	if user.UserID == counterparty.UserID {
+		return nil, fmt.Errorf("cannot settle debts with yourself")
+	}
+
+	debts, err := s.listDebts(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := []types.Transfer{}
+	paid := map[*debt]int64{}
+	for _, settlement := range planSettlements(debts) {
+		transfer := settlement.transfer
+		between := (transfer.From.UserID == user.UserID && transfer.To.UserID == counterparty.UserID) ||
+			(transfer.From.UserID == counterparty.UserID && transfer.To.UserID == user.UserID)
+		if !between {
+			continue
+		}
+
+		if transfer.To.UserID != user.UserID && !user.Admin {
+			return nil, fmt.Errorf(
+				"only %s or an admin can confirm receiving %s, pay it with /billing_pay and a receipt",
+				transfer.To.TelegramName,
+				money.Format(transfer.Amount, transfer.Currency),
+			)
+		}
+
+		transfers = append(transfers, transfer)
+		for d, value := range settlement.paid {
+			paid[d] += value
+		}
+	}
+
+	if len(transfers) == 0 {
+		return nil, fmt.Errorf("there are no transfers to settle with %s, see /balances", counterparty.TelegramName)
+	}
+
+	entries := []*types.PaymentEntry{}
+	for i := range debts {
+		d := &debts[i]
+		value, ok := paid[d]
+		if !ok {
+			continue
+		}
+
+		entry := &types.PaymentEntry{
+			BillingID:  d.billing.ID,
+			UserID:     d.debtor.UserID,
+			Amount:     debtAmount(d, value),
+			Note:       fmt.Sprintf("settled between %s and %s", user.TelegramName, counterparty.TelegramName),
+			RecordedBy: user.UserID,
+			CreatedAt:  time.Now(),
+		}
+		entry.Fee = min(entry.Amount, d.payment.Penalty.Due)
+		entry.Amount -= entry.Fee
+
+		if entry.ID, err = uuid.NewV7(); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := s.repository.CreatePaymentEntries(ctx, entries); err != nil {
+		return nil, err
+	}
+
+	audit.entry.Details = fmt.Sprintf("with %s, %d payments", counterparty.TelegramName, len(entries))
+	return transfers, nil
+}
+
+// debtAmount converts the value of a debt paid in the settlement currency
+// back to the billing currency, capped to the debt
+func debtAmount(d *debt, value int64) int64 {
+	if value >= d.value {
+		return d.amount
+	}
+	if d.billing.Conversion == nil {
+		return value
+	}
+
+	conversion := d.billing.Conversion
+	return min(money.Convert(value, conversion.Currency, d.billing.Currency, 1/conversion.Rate), d.amount)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+func TestPlanSettlements(t *testing.T) {
+	users := map[string]types.User{}
+	for i, name := range []string{"A", "B", "C", "D"} {
+		users[name] = types.User{
+			UserID:       uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", i+1)),
+			TelegramName: name,
+		}
+	}
+	owes := func(debtor, creditor string, value int64, currency string) debt {
+		return debt{
+			debtor:   users[debtor],
+			creditor: users[creditor],
+			amount:   value,
+			value:    value,
+			currency: currency,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		debts     []debt
+		transfers []string
+	}{
+		{
+			name:      "single debt",
+			debts:     []debt{owes("A", "B", 100, "BRL")},
+			transfers: []string{"A->B 100 BRL"},
+		},
+		{
+			name:      "chain",
+			debts:     []debt{owes("A", "B", 100, "BRL"), owes("B", "C", 100, "BRL")},
+			transfers: []string{"A->C 100 BRL"},
+		},
+		{
+			name:      "partial chain",
+			debts:     []debt{owes("A", "B", 100, "BRL"), owes("B", "C", 40, "BRL")},
+			transfers: []string{"A->B 60 BRL", "A->C 40 BRL"},
+		},
+		{
+			name:      "cycle",
+			debts:     []debt{owes("A", "B", 100, "BRL"), owes("B", "C", 100, "BRL"), owes("C", "A", 100, "BRL")},
+			transfers: []string{},
+		},
+		{
+			name:      "uneven cycle",
+			debts:     []debt{owes("A", "B", 100, "BRL"), owes("B", "C", 50, "BRL"), owes("C", "A", 50, "BRL")},
+			transfers: []string{"A->B 50 BRL"},
+		},
+		{
+			name:      "largest debtor first",
+			debts:     []debt{owes("A", "B", 100, "BRL"), owes("D", "B", 50, "BRL"), owes("D", "C", 70, "BRL")},
+			transfers: []string{"D->B 50 BRL", "A->B 100 BRL", "D->C 70 BRL"},
+		},
+		{
+			name: "multiple currencies",
+			debts: []debt{
+				owes("A", "B", 100, "BRL"),
+				owes("B", "A", 30, "USD"),
+				owes("A", "B", 20, "USD"),
+			},
+			transfers: []string{"A->B 100 BRL", "B->A 10 USD"},
+		},
+		{
+			name:      "currencies do not net",
+			debts:     []debt{owes("A", "B", 100, "USD"), owes("B", "A", 100, "BRL")},
+			transfers: []string{"B->A 100 BRL", "A->B 100 USD"},
+		},
+		{
+			name:      "zero debts",
+			debts:     []debt{owes("A", "B", 0, "BRL")},
+			transfers: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settlements := planSettlements(test.debts)
+
+			transfers := []string{}
+			paid := map[*debt]int64{}
+			for _, settlement := range settlements {
+				transfer := settlement.transfer
+				transfers = append(transfers, fmt.Sprintf(
+					"%s->%s %d %s", transfer.From.TelegramName, transfer.To.TelegramName, transfer.Amount, transfer.Currency,
+				))
+
+				for d, value := range settlement.paid {
+					if d.currency != transfer.Currency {
+						t.Fatalf("transfer in %s pays a debt in %s", transfer.Currency, d.currency)
+					}
+					paid[d] += value
+				}
+			}
+			if !slices.Equal(transfers, test.transfers) {
+				t.Fatalf("transfers %v, expected %v", transfers, test.transfers)
+			}
+
+			// The debts left after the transfers net out for every user
+			net := map[string]int64{}
+			for i := range test.debts {
+				d := &test.debts[i]
+				left := d.value - paid[d]
+				if left < 0 {
+					t.Fatalf("paid %d of a debt of %d", paid[d], d.value)
+				}
+				net[d.debtor.TelegramName+" "+d.currency] -= left
+				net[d.creditor.TelegramName+" "+d.currency] += left
+			}
+			for user, amount := range net {
+				if amount != 0 {
+					t.Fatalf("%s left with a balance of %d", user, amount)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// budgetThresholds are the percents of a budget that post an alert, highest
+// first
+var budgetThresholds = []int{100, 80}
+
+// ParseBudgetPeriod parses the period of a budget, empty periods are monthly
+func ParseBudgetPeriod(period string) (types.Recurrence, error) {
+	period = strings.ToLower(strings.TrimSpace(period))
+	if period == "" {
+		return types.RecurrenceMonthly, nil
+	}
+
+	switch types.Recurrence(period) {
+	case types.RecurrenceWeekly, types.RecurrenceMonthly, types.RecurrenceYearly:
+		return types.Recurrence(period), nil
+	}
+
+	return types.RecurrenceNone, fmt.Errorf("invalid budget period: %s", period)
+}
+
+// CreateBudget validates the budget, groups have a single budget per category
+// and period
+func (s *Service) CreateBudget(ctx context.Context, budget *types.Budget) (_ *types.Budget, err error) {
+	audit := s.startAudit(ctx, "budget_add")
+	defer s.finishAudit(ctx, audit, &err)
+
+	if budget.Amount <= 0 {
+		return nil, fmt.Errorf("invalid budget amount: %d", budget.Amount)
+	}
+
+	if budget.Period, err = ParseBudgetPeriod(string(budget.Period)); err != nil {
+		return nil, err
+	}
+
+	budget.Currency, err = money.NormalizeCurrency(budget.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if budget.Category != "" {
+		if budget.Category, err = normalizeLabel(budget.Category); err != nil {
+			return nil, err
+		}
+	}
+	audit.entry.Details = fmt.Sprintf("%s %s budget of %s", budget.Period, budgetScope(budget), money.Format(budget.Amount, budget.Currency))
+
+	budgets, err := s.repository.ListBudgets(ctx, budget.ChatID)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range budgets {
+		if existing.Category == budget.Category && existing.Period == budget.Period {
+			return nil, fmt.Errorf("budget already exist")
+		}
+	}
+
+	budget.ID, err = uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	budget.CreatedAt = time.Now()
+
+	if err := s.repository.CreateBudget(ctx, budget); err != nil {
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+func (s *Service) ListBudgets(ctx context.Context, chatID int64) ([]*types.Budget, error) {
+	return s.repository.ListBudgets(ctx, chatID)
+}
+
+// ListBudgetStatus returns how much of each budget of the group was spent in
+// the period containing now
+func (s *Service) ListBudgetStatus(ctx context.Context, chatID int64, now time.Time) ([]types.BudgetStatus, error) {
+	budgets, err := s.repository.ListBudgets(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.budgetStatus(ctx, budgets, now)
+}
+
+// ListBudgetAlerts returns the budgets of every group that reached a
+// threshold not alerted yet in the current period, only the highest threshold
+// reached is returned
+func (s *Service) ListBudgetAlerts(ctx context.Context, now time.Time) ([]types.BudgetAlert, error) {
+	budgets, err := s.repository.ListBudgets(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := s.budgetStatus(ctx, budgets, now)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := []types.BudgetAlert{}
+	for _, status := range statuses {
+		budget := status.Budget
+		for _, threshold := range budgetThresholds {
+			if status.Percent < threshold {
+				continue
+			}
+
+			if !budget.AlertedPeriod.Equal(status.PeriodStart) || budget.AlertedPercent < threshold {
+				alerts = append(alerts, types.BudgetAlert{Status: status, Threshold: threshold})
+			}
+			break
+		}
+	}
+
+	return alerts, nil
+}
+
+// MarkBudgetAlerted records the alert as posted, lower thresholds of the
+// period are not alerted after it
+func (s *Service) MarkBudgetAlerted(ctx context.Context, alert types.BudgetAlert) error {
+	budget := alert.Status.Budget
+	budget.AlertedPeriod = alert.Status.PeriodStart
+	budget.AlertedPercent = alert.Threshold
+	return s.repository.UpdateBudgetAlert(ctx, budget)
+}
+
+// budgetStatus sums the value of the billings created in the current period
+// of each budget, the billings of a group are listed once
+func (s *Service) budgetStatus(ctx context.Context, budgets []*types.Budget, now time.Time) ([]types.BudgetStatus, error) {
+	groups := map[int64][]*types.Billing{}
+	statuses := []types.BudgetStatus{}
+
+	for _, budget := range budgets {
+		billings, ok := groups[budget.ChatID]
+		if !ok {
+			var err error
+			billings, err = s.repository.ListBillings(ctx, types.BillingFilter{ChatID: budget.ChatID})
+			if err != nil {
+				return nil, err
+			}
+			groups[budget.ChatID] = billings
+		}
+
+		start, end := budgetPeriod(budget.Period, now)
+		status := types.BudgetStatus{Budget: budget, PeriodStart: start, PeriodEnd: end}
+
+		for _, billing := range billings {
+			if billing.CreatedAt.Before(start) || !billing.CreatedAt.Before(end) {
+				continue
+			}
+			if budget.Category != "" && billing.Category != budget.Category {
+				continue
+			}
+
+			rate, ok, err := s.exchangeRate(ctx, billing.Currency, budget.Currency, billing.CreatedAt)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+
+			status.Billings++
+			status.Spent += money.Convert(billing.Value, billing.Currency, budget.Currency, rate)
+		}
+
+		status.Percent = int(status.Spent * 100 / budget.Amount)
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// budgetPeriod returns the period of the budget containing now, weeks start on
+// monday
+func budgetPeriod(period types.Recurrence, now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch period {
+	case types.RecurrenceWeekly:
+		start := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7)
+	case types.RecurrenceYearly:
+		start := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, 0)
+	}
+
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// budgetScope describes what the budget covers
+func budgetScope(budget *types.Budget) string {
+	if budget.Category == "" {
+		return "group"
+	}
+	return budget.Category
+}
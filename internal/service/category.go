@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"misaki/types"
+)
+
+// normalizeLabel lowercases a category or tag, labels are single words
+// since tags are stored separated by comma
+func normalizeLabel(label string) (string, error) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" || strings.ContainsAny(label, " ,:") {
+		return "", fmt.Errorf("invalid label informed: %s", label)
+	}
+	return label, nil
+}
+
+// normalizeLabels validates the category and tags of the billing, removing
+// duplicated tags
+func normalizeLabels(billing *types.Billing) error {
+	if billing.Category != "" {
+		category, err := normalizeLabel(billing.Category)
+		if err != nil {
+			return err
+		}
+		billing.Category = category
+	}
+
+	tags := []string{}
+	for _, tag := range billing.Tags {
+		tag, err := normalizeLabel(tag)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	billing.Tags = tags
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+
+	"misaki/types"
+)
+
+// SaveConversation persists the conversation so it is resumed after a restart
+func (s *Service) SaveConversation(ctx context.Context, conversation *types.Conversation) error {
+	if conversation.Data == nil {
+		conversation.Data = map[string]string{}
+	}
+	return s.repository.SaveConversation(ctx, conversation)
+}
+
+func (s *Service) DeleteConversation(ctx context.Context, conversation *types.Conversation) error {
+	return s.repository.DeleteConversation(ctx, conversation.ChatID, conversation.TelegramID)
+}
+
+func (s *Service) ListConversations(ctx context.Context) ([]*types.Conversation, error) {
+	return s.repository.ListConversations(ctx)
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"misaki/internal/export"
+	"misaki/types"
+)
+
+// ExportBillings writes a spreadsheet with the payments of the billing, or of
+// every billing of the group when it is nil (every group for chat 0)
+func (s *Service) ExportBillings(ctx context.Context, w io.Writer, chatID int64, billing *types.Billing, format export.Format) error {
+	var targets []*types.Billing
+	if billing != nil {
+		targets = []*types.Billing{billing}
+	} else {
+		var err error
+		targets, err = s.ListBillings(ctx, types.BillingFilter{ChatID: chatID})
+		if err != nil {
+			return err
+		}
+	}
+
+	billings := make([]*types.Billing, 0, len(targets))
+	for _, target := range targets {
+		detailed, err := s.GetBilling(ctx, target)
+		if err != nil {
+			return err
+		}
+		billings = append(billings, detailed)
+	}
+
+	return export.Write(w, format, billings)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// checkMember fails when the user is not a member of the group, billings
+// created before groups existed (chat 0) accept any user
+func (s *Service) checkMember(ctx context.Context, chatID int64, userID uuid.UUID) error {
+	if chatID == 0 || userID == uuid.Nil {
+		return nil
+	}
+
+	member, err := s.repository.IsGroupMember(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !member {
+		return fmt.Errorf("user is not a member of this group, use /user_add in it first")
+	}
+	return nil
+}
+
+// CheckMember validates a participant before the billing is created, so
+// guided flows can ask for another one
+func (s *Service) CheckMember(ctx context.Context, chatID int64, userID uuid.UUID) error {
+	return s.checkMember(ctx, chatID, userID)
+}
+
+// JoinGroup adds the user to the group, creating the group on its first member
+func (s *Service) JoinGroup(ctx context.Context, group *types.Group, user *types.User) (err error) {
+	audit := s.startAudit(ctx, "group_join")
+	audit.entry.ChatID = group.ChatID
+	audit.user(user)
+	defer s.finishAudit(ctx, audit, &err)
+
+	member, err := s.repository.IsGroupMember(ctx, group.ChatID, user.UserID)
+	if err != nil {
+		return err
+	}
+
+	if member {
+		return fmt.Errorf("user already exist in this group")
+	}
+
+	group.CreatedAt = time.Now()
+	if err := s.repository.SaveGroup(ctx, group); err != nil {
+		return err
+	}
+
+	return s.repository.AddGroupMember(ctx, group.ChatID, user.UserID)
+}
+
+// ClaimLegacyBillings moves the billings created before groups existed to
+// the group, returning how many billings were moved
+func (s *Service) ClaimLegacyBillings(ctx context.Context, group *types.Group) (claimed int64, err error) {
+	audit := s.startAudit(ctx, "group_claim")
+	audit.entry.ChatID = group.ChatID
+	defer s.finishAudit(ctx, audit, &err)
+
+	if group.ChatID == 0 {
+		return 0, fmt.Errorf("invalid group")
+	}
+
+	group.CreatedAt = time.Now()
+	if err := s.repository.SaveGroup(ctx, group); err != nil {
+		return 0, err
+	}
+
+	claimed, err = s.repository.ClaimLegacyBillings(ctx, group.ChatID)
+	audit.entry.Details = fmt.Sprintf("%d billings claimed", claimed)
+	return claimed, err
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// billingChanges compares the billing before and after an update and returns
+// one change for every field with a different value
+func (s *Service) billingChanges(ctx context.Context, before, after *types.Billing, actor *types.User) ([]types.BillingChange, error) {
+	oldPayer, err := s.payerName(ctx, before.PayerID)
+	if err != nil {
+		return nil, err
+	}
+	newPayer, err := s.payerName(ctx, after.PayerID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"name", before.Name, after.Name},
+		{"value", money.Format(before.Value, before.Currency), money.Format(after.Value, after.Currency)},
+		{"due", formatHistoryDate(before.DueAt), formatHistoryDate(after.DueAt)},
+		{"payer", oldPayer, newPayer},
+		{"category", before.Category, after.Category},
+		{"tags", strings.Join(before.Tags, ","), strings.Join(after.Tags, ",")},
+		{"late_fee", FormatLateFee(before.LateFee, before.Currency), FormatLateFee(after.LateFee, after.Currency)},
+	}
+
+	now := time.Now()
+	changes := []types.BillingChange{}
+	for _, field := range fields {
+		if field.old == field.new {
+			continue
+		}
+
+		id, err := uuid.NewV7()
+		if err != nil {
+			return nil, err
+		}
+
+		change := types.BillingChange{
+			ID:        id,
+			BillingID: after.ID,
+			Field:     field.name,
+			OldValue:  field.old,
+			NewValue:  field.new,
+			CreatedAt: now,
+		}
+		if actor != nil {
+			change.ActorID = actor.UserID
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// payerName returns the name recorded in the history for the payer
+func (s *Service) payerName(ctx context.Context, payerID uuid.UUID) (string, error) {
+	if payerID == uuid.Nil {
+		return "", nil
+	}
+
+	payer, err := s.repository.GetUser(ctx, &types.User{UserID: payerID})
+	if err != nil {
+		return "", err
+	}
+
+	if payer.TelegramName != "" {
+		return payer.TelegramName, nil
+	}
+	if payer.TelegramID != types.TELEGRAM_ID_EMPTY {
+		return fmt.Sprintf("%d", payer.TelegramID), nil
+	}
+	return payer.UserID.String(), nil
+}
+
+func formatHistoryDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
+
+// ListBillingHistory returns the changes made to the billing, oldest first
+func (s *Service) ListBillingHistory(ctx context.Context, billing *types.Billing) (*types.Billing, []types.BillingChange, error) {
+	billing, err := s.GetBilling(ctx, billing)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes, err := s.repository.ListBillingHistory(ctx, billing.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Several changes are usually made by the same user
+	actors := map[uuid.UUID]*types.User{}
+	for i := range changes {
+		change := &changes[i]
+		if change.ActorID == uuid.Nil {
+			continue
+		}
+
+		actor, ok := actors[change.ActorID]
+		if !ok {
+			actor, err = s.repository.GetUser(ctx, &types.User{UserID: change.ActorID})
+			if err != nil {
+				return nil, nil, err
+			}
+			actors[change.ActorID] = actor
+		}
+		change.Actor = actor
+	}
+
+	return billing, changes, nil
+}
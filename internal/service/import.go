@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// ParseBillingImport reads a CSV file with the columns name, value, due date
+// (YYYY-MM-DD, may be empty), comma separated user identifiers and an
+// optional currency. A first line starting with "name" is a header
+func ParseBillingImport(r io.Reader) ([]types.BillingImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv file: %w", err)
+	}
+
+	rows := []types.BillingImportRow{}
+	for i, record := range records {
+		if i == 0 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), "name") {
+			continue
+		}
+
+		row := types.BillingImportRow{Line: i + 1}
+		if len(record) < 4 || len(record) > 5 {
+			row.Error = fmt.Sprintf("expected 4 or 5 columns, received %d", len(record))
+			rows = append(rows, row)
+			continue
+		}
+
+		row.Name = strings.TrimSpace(record[0])
+		row.Value = strings.TrimSpace(record[1])
+		row.Due = strings.TrimSpace(record[2])
+		for _, user := range strings.Split(record[3], ",") {
+			if user = strings.TrimSpace(user); user != "" {
+				row.Users = append(row.Users, user)
+			}
+		}
+		if len(record) == 5 {
+			row.Currency = strings.TrimSpace(record[4])
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("csv file has no billings")
+	}
+
+	return rows, nil
+}
+
+// ImportBillings validates every row, creating all the billings of the group
+// and their associations in a single transaction. Nothing is created on dry runs or
+// when any row is invalid, the returned bool tells if the rows were imported
+func (s *Service) ImportBillings(ctx context.Context, chatID int64, rows []types.BillingImportRow, dryRun bool) ([]types.BillingImportRow, bool, error) {
+	names := map[string]bool{}
+	billings := []*types.Billing{}
+	valid := true
+
+	for i := range rows {
+		row := &rows[i]
+		row.Billing = nil
+		if row.Error == "" {
+			billing, err := s.planImportRow(ctx, chatID, row)
+			if err != nil {
+				row.Error = err.Error()
+			} else if names[billing.Name] {
+				row.Error = "duplicated billing name in file"
+			} else {
+				names[billing.Name] = true
+				row.Billing = billing
+				billings = append(billings, billing)
+			}
+		}
+
+		if row.Error != "" {
+			valid = false
+		}
+	}
+
+	if dryRun || !valid {
+		return rows, false, nil
+	}
+
+	audit := s.startAudit(ctx, "billing_import")
+	audit.entry.Details = fmt.Sprintf("%d billings", len(billings))
+
+	err := s.repository.CreateBillings(ctx, billings)
+	s.finishAudit(ctx, audit, &err)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return rows, true, nil
+}
+
+func (s *Service) planImportRow(ctx context.Context, chatID int64, row *types.BillingImportRow) (*types.Billing, error) {
+	currency, err := money.NormalizeCurrency(row.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := money.Parse(row.Value, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %s", row.Value)
+	}
+
+	billing := &types.Billing{
+		Name:     row.Name,
+		Value:    value,
+		Currency: currency,
+		ChatID:   chatID,
+	}
+
+	if row.Due != "" {
+		billing.DueAt, err = time.Parse("2006-01-02", row.Due)
+		if err != nil {
+			return nil, fmt.Errorf("invalid due date %s, expected: YYYY-MM-DD", row.Due)
+		}
+	}
+
+	if err := s.prepareBilling(ctx, billing); err != nil {
+		return nil, err
+	}
+
+	users := map[uuid.UUID]bool{}
+	for _, id := range row.Users {
+		user, err := s.findImportUser(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.checkMember(ctx, chatID, user.UserID); err != nil {
+			return nil, err
+		}
+
+		if users[user.UserID] {
+			return nil, fmt.Errorf("user %s informed twice", id)
+		}
+		users[user.UserID] = true
+
+		billing.Payments = append(billing.Payments, types.Payment{
+			BillingID: billing.ID,
+			UserID:    user.UserID,
+			UserInfo:  *user,
+		})
+	}
+
+	return billing, nil
+}
+
+// findImportUser searches an user by Telegram ID or user ID
+func (s *Service) findImportUser(ctx context.Context, id string) (*types.User, error) {
+	user := &types.User{}
+	if telegramID, err := strconv.ParseInt(id, 10, 64); err == nil {
+		user.TelegramID = telegramID
+	} else if userID, err := uuid.Parse(id); err == nil {
+		user.UserID = userID
+	} else {
+		return nil, fmt.Errorf("invalid user identifier %s", id)
+	}
+
+	user, err := s.GetUser(ctx, user)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user %s not found", id)
+	}
+	return user, err
+}
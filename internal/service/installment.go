@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+const maxInstallments = 120
+
+// isInstallment reports whether the billing belongs to an installment plan
+func isInstallment(billing *types.Billing) bool {
+	return billing.Installments > 0
+}
+
+// validateInstallments checks the number of installments of a new billing
+func validateInstallments(billing *types.Billing) error {
+	if billing.Installments == 0 {
+		return nil
+	}
+
+	if billing.Installments < 2 || billing.Installments > maxInstallments {
+		return fmt.Errorf("invalid number of installments %d, expected 2 to %d", billing.Installments, maxInstallments)
+	}
+
+	if billing.Recurrence != types.RecurrenceNone {
+		return fmt.Errorf("recurring billings cannot be paid in installments")
+	}
+
+	return nil
+}
+
+// planInstallments splits the value of a prepared billing into its
+// installments, the billing itself becomes the first installment and the
+// others point to it. Installments are due monthly starting at the billing
+// due date, or a month after its creation when it has none
+func planInstallments(billing *types.Billing) ([]*types.Billing, error) {
+	weights := make([]float64, billing.Installments)
+	for i := range weights {
+		weights[i] = 1
+	}
+	amounts := money.Allocate(billing.Value, weights)
+
+	firstDue := billing.DueAt
+	if firstDue.IsZero() {
+		firstDue = dateInMonth(billing.CreatedAt.Year(), billing.CreatedAt.Month()+1, billing.CreatedAt.Day(), billing.CreatedAt)
+	}
+
+	installments := make([]*types.Billing, 0, billing.Installments)
+	for i, amount := range amounts {
+		dueAt := dateInMonth(firstDue.Year(), firstDue.Month()+time.Month(i), firstDue.Day(), firstDue)
+
+		if i == 0 {
+			billing.Value = amount
+			billing.DueAt = dueAt
+			installments = append(installments, billing)
+			continue
+		}
+
+		id, err := uuid.NewV7()
+		if err != nil {
+			return nil, err
+		}
+
+		installments = append(installments, &types.Billing{
+			ID:           id,
+			Name:         fmt.Sprintf("%s#%d", billing.Name, i+1),
+			Value:        amount,
+			Currency:     billing.Currency,
+			CreatedAt:    billing.CreatedAt,
+			DueAt:        dueAt,
+			PayerID:      billing.PayerID,
+			Category:     billing.Category,
+			Tags:         billing.Tags,
+			LateFee:      billing.LateFee,
+			ChatID:       billing.ChatID,
+			ParentID:     billing.ID,
+			Cycle:        i + 1,
+			PeriodStart:  billing.PeriodStart,
+			Installments: billing.Installments,
+		})
+	}
+
+	return installments, nil
+}
+
+// listInstallments loads every installment of the plan with its payments
+func (s *Service) listInstallments(ctx context.Context, billing *types.Billing) ([]*types.Billing, error) {
+	series, err := s.repository.ListBillingCycles(ctx, seriesID(billing))
+	if err != nil {
+		return nil, err
+	}
+
+	installments := make([]*types.Billing, 0, len(series))
+	for _, installment := range series {
+		detailed, err := s.GetBilling(ctx, &types.Billing{ID: installment.ID})
+		if err != nil {
+			return nil, err
+		}
+		installments = append(installments, detailed)
+	}
+
+	return installments, nil
+}
+
+// countPaidInstallments counts the installments of the plan with users
+// associated and nothing outstanding in any of their payments
+func (s *Service) countPaidInstallments(ctx context.Context, seriesID uuid.UUID) (int, error) {
+	series, err := s.repository.ListBillingCycles(ctx, seriesID)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, installment := range series {
+		billing, err := s.repository.GetBilling(ctx, &types.Billing{ID: installment.ID})
+		if err != nil {
+			return 0, err
+		}
+		if len(billing.Payments) == 0 {
+			continue
+		}
+
+		splitBilling(billing)
+
+		entries, err := s.repository.ListPaymentEntries(ctx, billing.ID)
+		if err != nil {
+			return 0, err
+		}
+		applyLedger(billing, entries)
+
+		paid := true
+		for _, payment := range billing.Payments {
+			if payment.Outstanding != 0 {
+				paid = false
+			}
+		}
+		if paid {
+			count++
+		}
+	}
+
+	return count, nil
+}
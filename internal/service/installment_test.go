@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+func TestPlanInstallments(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name         string
+		value        int64
+		installments int
+		createdAt    time.Time
+		dueAt        time.Time
+		amounts      []int64
+		dueDates     []time.Time
+	}{
+		{
+			name:         "even",
+			value:        30000,
+			installments: 3,
+			createdAt:    date(2026, 3, 1),
+			dueAt:        date(2026, 3, 10),
+			amounts:      []int64{10000, 10000, 10000},
+			dueDates:     []time.Time{date(2026, 3, 10), date(2026, 4, 10), date(2026, 5, 10)},
+		},
+		{
+			name:         "remainder cents",
+			value:        10000,
+			installments: 3,
+			createdAt:    date(2026, 1, 5),
+			dueAt:        date(2026, 1, 31),
+			amounts:      []int64{3334, 3333, 3333},
+			dueDates:     []time.Time{date(2026, 1, 31), date(2026, 2, 28), date(2026, 3, 31)},
+		},
+		{
+			name:         "several remainder cents",
+			value:        1000,
+			installments: 6,
+			createdAt:    date(2026, 11, 30),
+			dueAt:        date(2026, 11, 30),
+			amounts:      []int64{167, 167, 167, 167, 166, 166},
+			dueDates: []time.Time{
+				date(2026, 11, 30), date(2026, 12, 30), date(2027, 1, 30),
+				date(2027, 2, 28), date(2027, 3, 30), date(2027, 4, 30),
+			},
+		},
+		{
+			name:         "without due date",
+			value:        101,
+			installments: 2,
+			createdAt:    date(2026, 1, 31),
+			amounts:      []int64{51, 50},
+			dueDates:     []time.Time{date(2026, 2, 28), date(2026, 3, 28)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			billing := &types.Billing{
+				ID:           uuid.New(),
+				Name:         "car",
+				Value:        test.value,
+				Currency:     "BRL",
+				CreatedAt:    test.createdAt,
+				DueAt:        test.dueAt,
+				Installments: test.installments,
+			}
+
+			installments, err := planInstallments(billing)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(installments) != test.installments || installments[0] != billing {
+				t.Fatalf("expected %d installments starting with the billing", test.installments)
+			}
+
+			amounts := []int64{}
+			dueDates := []time.Time{}
+			for i, installment := range installments {
+				amounts = append(amounts, installment.Value)
+				dueDates = append(dueDates, installment.DueAt)
+
+				if i == 0 {
+					continue
+				}
+				if installment.ParentID != billing.ID || installment.Cycle != i+1 {
+					t.Fatalf("installment %d has parent %s and cycle %d", i+1, installment.ParentID, installment.Cycle)
+				}
+				if name := fmt.Sprintf("car#%d", i+1); installment.Name != name {
+					t.Fatalf("installment named %s, expected %s", installment.Name, name)
+				}
+			}
+			if !slices.Equal(amounts, test.amounts) {
+				t.Fatalf("amounts %v, expected %v", amounts, test.amounts)
+			}
+			if !slices.EqualFunc(dueDates, test.dueDates, time.Time.Equal) {
+				t.Fatalf("due dates %v, expected %v", dueDates, test.dueDates)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+)
+
+// hasLateFee reports whether the policy charges anything
+func hasLateFee(policy types.LateFee) bool {
+	return policy.Flat > 0 || policy.DailyRate > 0
+}
+
+func validateLateFee(policy types.LateFee) error {
+	if policy.Flat < 0 || policy.Cap < 0 {
+		return fmt.Errorf("late fees must be positive")
+	}
+
+	if policy.DailyRate < 0 || policy.DailyRate > 100 {
+		return fmt.Errorf("invalid daily interest %g%%, expected 0 to 100", policy.DailyRate)
+	}
+
+	return nil
+}
+
+// applyLateFees computes the penalty of every payment still owing after the
+// due date, the billing is due until the end of the due day and the flat fee
+// and the first day of interest are charged on the next one. Each day of
+// interest accrues on the part of the share outstanding when the day ended,
+// so later payments do not change the interest of past days. Penalties stop
+// once the share is paid, payments pay the late fees first
+func applyLateFees(billing *types.Billing, now time.Time) {
+	daysLate := 0
+	if !billing.DueAt.IsZero() && now.After(billing.DueAt) {
+		daysLate = int(now.Sub(billing.DueAt) / (24 * time.Hour))
+	}
+
+	for i := range billing.Payments {
+		payment := &billing.Payments[i]
+		penalty := types.Penalty{}
+		for _, entry := range payment.Entries {
+			penalty.Paid += entry.Fee
+		}
+
+		if hasLateFee(billing.LateFee) && daysLate > 0 && payment.Outstanding > 0 {
+			balances := dailyBalances(payment, billing.DueAt, daysLate)
+			penalty.DaysLate = daysLate
+			penalty.Fee, penalty.Interest = accrueLateFee(billing.LateFee, balances)
+			penalty.Due = max(penalty.Fee+penalty.Interest-penalty.Paid, 0)
+		}
+
+		payment.Penalty = penalty
+		payment.AmountDue = payment.Outstanding + penalty.Due
+	}
+}
+
+// dailyBalances returns the part of the share outstanding at the end of each
+// day late, from the ledger entries recorded until then
+func dailyBalances(payment *types.Payment, dueAt time.Time, daysLate int) []int64 {
+	balances := make([]int64, daysLate)
+	for day := range balances {
+		end := dueAt.Add(time.Duration(day+1) * 24 * time.Hour)
+
+		paid := int64(0)
+		for _, entry := range payment.Entries {
+			if !entry.CreatedAt.After(end) {
+				paid += entry.Amount
+			}
+		}
+		balances[day] = max(payment.Amount-paid, 0)
+	}
+	return balances
+}
+
+// accrueLateFee returns the flat fee and the interest of the daily balances,
+// the interest is reduced first to respect the cap
+func accrueLateFee(policy types.LateFee, balances []int64) (int64, int64) {
+	fee := policy.Flat
+
+	total := int64(0)
+	for _, balance := range balances {
+		total += balance
+	}
+	interest := int64(math.Round(float64(total) * policy.DailyRate / 100))
+
+	if policy.Cap > 0 {
+		fee = min(fee, policy.Cap)
+		interest = min(interest, policy.Cap-fee)
+	}
+
+	return fee, interest
+}
+
+// ParseLateFeeRate reads a daily interest rate, like 0.5%
+func ParseLateFeeRate(raw string) (float64, error) {
+	rate, err := strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid daily interest, expected percentage, received: %s", raw)
+	}
+	return rate, nil
+}
+
+// FormatLateFee describes the policy, like "5.00 BRL + 1% a day (cap 20.00 BRL)"
+func FormatLateFee(policy types.LateFee, currency string) string {
+	if !hasLateFee(policy) {
+		return ""
+	}
+
+	parts := []string{}
+	if policy.Flat > 0 {
+		parts = append(parts, money.Format(policy.Flat, currency))
+	}
+	if policy.DailyRate > 0 {
+		parts = append(parts, fmt.Sprintf("%g%% a day", policy.DailyRate))
+	}
+
+	text := strings.Join(parts, " + ")
+	if policy.Cap > 0 {
+		text += fmt.Sprintf(" (cap %s)", money.Format(policy.Cap, currency))
+	}
+	return text
+}
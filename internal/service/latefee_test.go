@@ -0,0 +1,114 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"misaki/types"
+)
+
+func TestApplyLateFees(t *testing.T) {
+	dueAt := time.Date(2026, 3, 10, 23, 59, 59, 0, time.UTC)
+	day := func(days float64) time.Time {
+		return dueAt.Add(time.Duration(days * float64(24*time.Hour)))
+	}
+	policy := types.LateFee{Flat: 500, DailyRate: 1}
+
+	tests := map[string]struct {
+		policy  types.LateFee
+		entries []types.PaymentEntry
+		now     time.Time
+		want    types.Penalty
+	}{
+		"before the due date": {
+			policy: policy,
+			now:    day(-1),
+			want:   types.Penalty{},
+		},
+		"due day not over": {
+			policy: policy,
+			now:    day(0.5),
+			want:   types.Penalty{},
+		},
+		"unpaid": {
+			policy: policy,
+			now:    day(10.1),
+			want:   types.Penalty{DaysLate: 10, Fee: 500, Interest: 1000, Due: 1500},
+		},
+		"partial payment keeps the interest of past days": {
+			policy: policy,
+			entries: []types.PaymentEntry{
+				{Amount: 5000, CreatedAt: day(3.5)},
+			},
+			now:  day(10.1),
+			want: types.Penalty{DaysLate: 10, Fee: 500, Interest: 300 + 7*50, Due: 500 + 300 + 7*50},
+		},
+		"fees paid are discounted": {
+			policy: policy,
+			entries: []types.PaymentEntry{
+				{Amount: 4000, Fee: 800, CreatedAt: day(3.5)},
+			},
+			now:  day(5.1),
+			want: types.Penalty{DaysLate: 5, Fee: 500, Interest: 300 + 2*60, Paid: 800, Due: 120},
+		},
+		"reverted payment accrues again": {
+			policy: policy,
+			entries: []types.PaymentEntry{
+				{Amount: 10000, CreatedAt: day(-1)},
+				{Amount: -10000, CreatedAt: day(2.5)},
+			},
+			now:  day(4.1),
+			want: types.Penalty{DaysLate: 4, Fee: 500, Interest: 2 * 100, Due: 700},
+		},
+		"paid share stops the penalty": {
+			policy: policy,
+			entries: []types.PaymentEntry{
+				{Amount: 10000, Fee: 700, CreatedAt: day(2.5)},
+			},
+			now:  day(10.1),
+			want: types.Penalty{Paid: 700},
+		},
+		"cap reduces the interest first": {
+			policy: types.LateFee{Flat: 500, DailyRate: 1, Cap: 800},
+			now:    day(10.1),
+			want:   types.Penalty{DaysLate: 10, Fee: 500, Interest: 300, Due: 800},
+		},
+		"cap below the flat fee": {
+			policy: types.LateFee{Flat: 500, DailyRate: 1, Cap: 300},
+			now:    day(10.1),
+			want:   types.Penalty{DaysLate: 10, Fee: 300, Interest: 0, Due: 300},
+		},
+		"cap with partial payment": {
+			policy: types.LateFee{DailyRate: 1, Cap: 250},
+			entries: []types.PaymentEntry{
+				{Amount: 5000, CreatedAt: day(1.5)},
+			},
+			now:  day(10.1),
+			want: types.Penalty{DaysLate: 10, Interest: 250, Due: 250},
+		},
+		"no policy": {
+			now:  day(10.1),
+			want: types.Penalty{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			billing := &types.Billing{
+				DueAt:    dueAt,
+				LateFee:  test.policy,
+				Payments: []types.Payment{{Amount: 10000}},
+			}
+			applyLedger(billing, test.entries)
+			applyLateFees(billing, test.now)
+
+			payment := billing.Payments[0]
+			if payment.Penalty != test.want {
+				t.Fatalf("penalty %+v, expected %+v", payment.Penalty, test.want)
+			}
+			if payment.AmountDue != payment.Outstanding+test.want.Due {
+				t.Fatalf("amount due %d, expected %d", payment.AmountDue, payment.Outstanding+test.want.Due)
+			}
+		})
+	}
+}
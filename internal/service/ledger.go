@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// applyLedger derives how much each user has paid and still owes from the
+// ledger entries of the billing
+func applyLedger(billing *types.Billing, entries []types.PaymentEntry) {
+	for i := range billing.Payments {
+		payment := &billing.Payments[i]
+		payment.Entries = []types.PaymentEntry{}
+		payment.PaidAmount = 0
+		payment.PaidAt = time.Time{}
+
+		for _, entry := range entries {
+			if entry.UserID != payment.UserID {
+				continue
+			}
+
+			payment.Entries = append(payment.Entries, entry)
+			payment.PaidAmount += entry.Amount
+			payment.PaidAt = entry.CreatedAt
+		}
+
+		payment.Outstanding = max(payment.Amount-payment.PaidAmount, 0)
+		payment.Paid = payment.PaidAmount > 0 && payment.Outstanding == 0
+		if !payment.Paid {
+			payment.PaidAt = time.Time{}
+		}
+
+		payment.Status = types.PaymentUnpaid
+		if payment.Paid {
+			payment.Status = types.PaymentPaid
+		}
+	}
+}
+
+// findPayment loads the billing and returns the association of the entry user
+func (s *Service) findPayment(ctx context.Context, entry *types.PaymentEntry) (*types.Billing, *types.Payment, error) {
+	if entry.BillingID == uuid.Nil || entry.UserID == uuid.Nil {
+		return nil, nil, fmt.Errorf("missing billing or user identifier")
+	}
+
+	billing, err := s.GetBilling(ctx, &types.Billing{ID: entry.BillingID})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range billing.Payments {
+		if billing.Payments[i].UserID == entry.UserID {
+			return billing, &billing.Payments[i], nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("payment association not found")
+}
+
+// RecordPayment adds an entry to the ledger, when no amount is informed the
+// whole balance of the user is paid. Late fees are paid before the share and
+// the part of the amount paying them is recorded as the entry fee
+func (s *Service) RecordPayment(ctx context.Context, entry *types.PaymentEntry) (_ *types.Payment, err error) {
+	audit := s.startAudit(ctx, "payment_record")
+	audit.billing(&types.Billing{ID: entry.BillingID})
+	audit.user(&types.User{UserID: entry.UserID})
+	defer s.finishAudit(ctx, audit, &err)
+
+	billing, payment, err := s.findPayment(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	audit.billing(billing)
+	audit.user(&payment.UserInfo)
+
+	if err := preparePayment(billing, payment, entry); err != nil {
+		return nil, err
+	}
+	audit.entry.Details = fmt.Sprintf("amount %s, late fee %s", money.Format(entry.Amount, billing.Currency), money.Format(entry.Fee, billing.Currency))
+
+	if err := s.createPaymentEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return s.appendEntry(billing, payment, *entry), nil
+}
+
+// preparePayment validates the amount of the entry against the balance of
+// the payment and splits the part paying the late fees
+func preparePayment(billing *types.Billing, payment *types.Payment, entry *types.PaymentEntry) error {
+	if entry.Amount < 0 {
+		return fmt.Errorf("payment amount must be positive")
+	}
+
+	if payment.AmountDue == 0 {
+		return fmt.Errorf("there is nothing left to pay")
+	}
+
+	if entry.Amount == 0 {
+		entry.Amount = payment.AmountDue
+	}
+
+	if entry.Amount > payment.AmountDue {
+		return fmt.Errorf(
+			"amount %s exceeds the remaining balance %s",
+			money.Format(entry.Amount, billing.Currency),
+			money.Format(payment.AmountDue, billing.Currency),
+		)
+	}
+
+	entry.Fee = min(entry.Amount, payment.Penalty.Due)
+	entry.Amount -= entry.Fee
+	return nil
+}
+
+// RevertPayment records an entry cancelling everything paid by the user,
+// previous entries are kept in the ledger
+func (s *Service) RevertPayment(ctx context.Context, entry *types.PaymentEntry) (_ *types.Payment, err error) {
+	audit := s.startAudit(ctx, "payment_revert")
+	audit.billing(&types.Billing{ID: entry.BillingID})
+	audit.user(&types.User{UserID: entry.UserID})
+	defer s.finishAudit(ctx, audit, &err)
+
+	billing, payment, err := s.findPayment(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	audit.billing(billing)
+	audit.user(&payment.UserInfo)
+
+	if payment.PaidAmount <= 0 && payment.Penalty.Paid <= 0 {
+		return nil, fmt.Errorf("there are no payments to revert")
+	}
+
+	entry.Amount = -payment.PaidAmount
+	entry.Fee = -payment.Penalty.Paid
+	if entry.Note == "" {
+		entry.Note = "payment reverted"
+	}
+
+	if err := s.createPaymentEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return s.appendEntry(billing, payment, *entry), nil
+}
+
+func (s *Service) createPaymentEntry(ctx context.Context, entry *types.PaymentEntry) error {
+	if err := newPaymentEntry(entry); err != nil {
+		return err
+	}
+
+	return s.repository.CreatePaymentEntry(ctx, entry)
+}
+
+// newPaymentEntry sets the identifier and the creation time of the entry
+func newPaymentEntry(entry *types.PaymentEntry) error {
+	var err error
+	entry.ID, err = uuid.NewV7()
+	if err != nil {
+		return err
+	}
+	entry.CreatedAt = time.Now()
+	return nil
+}
+
+// appendEntry updates the derived fields of the payment with a new entry
+func (s *Service) appendEntry(billing *types.Billing, payment *types.Payment, entry types.PaymentEntry) *types.Payment {
+	updated := &types.Billing{
+		DueAt:    billing.DueAt,
+		LateFee:  billing.LateFee,
+		Payments: []types.Payment{*payment},
+	}
+	applyLedger(updated, append(payment.Entries, entry))
+	applyLateFees(updated, time.Now())
+	return &updated.Payments[0]
+}
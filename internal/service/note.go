@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// latestNotes is how many notes are loaded with the billing
+	latestNotes   = 5
+	maxNoteLength = 1000
+)
+
+// AddBillingNote leaves a note on the billing, the author must be a member of
+// the group. Notes need a text or an attached file
+func (s *Service) AddBillingNote(ctx context.Context, billing *types.Billing, note *types.BillingNote) (_ *types.BillingNote, err error) {
+	audit := s.startAudit(ctx, "billing_note")
+	audit.billing(billing)
+	defer s.finishAudit(ctx, audit, &err)
+
+	billing, err = s.GetBilling(ctx, billing)
+	if err != nil {
+		return nil, err
+	}
+	audit.billing(billing)
+
+	if err := s.checkMember(ctx, billing.ChatID, note.AuthorID); err != nil {
+		return nil, err
+	}
+
+	note.Text = strings.TrimSpace(note.Text)
+	if note.Text == "" && note.FileID == "" {
+		return nil, fmt.Errorf("note cannot be empty")
+	}
+	if utf8.RuneCountInString(note.Text) > maxNoteLength {
+		return nil, fmt.Errorf("note cannot be longer than %d characters", maxNoteLength)
+	}
+
+	note.ID, err = uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	note.BillingID = billing.ID
+	note.CreatedAt = time.Now()
+
+	if err := s.repository.CreateBillingNote(ctx, note); err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
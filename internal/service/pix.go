@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"misaki/internal/pix"
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// SetUserPix registers the PIX key and city used to receive payments, an
+// empty key removes it
+func (s *Service) SetUserPix(ctx context.Context, user *types.User, key, city string) (err error) {
+	audit := s.startAudit(ctx, "pix_key")
+	audit.user(user)
+	defer s.finishAudit(ctx, audit, &err)
+
+	if user.UserID == uuid.Nil && user.TelegramID <= 0 {
+		return fmt.Errorf("missing identifiers to update user")
+	}
+
+	key = strings.TrimSpace(key)
+	city = strings.TrimSpace(city)
+	if key != "" {
+		if err := pix.ValidateKey(key); err != nil {
+			return err
+		}
+		if city == "" {
+			return fmt.Errorf("pix city cannot be empty")
+		}
+	} else {
+		city = ""
+	}
+
+	user.PixKey = key
+	user.PixCity = city
+	return s.repository.UpdateUserPix(ctx, user)
+}
+
+// GetPixCharge builds the PIX payload for the amount the user still owes in
+// the billing, paid to the billing payer or, when it has none, to an admin
+// with a PIX key. The billing must be loaded with GetBilling
+func (s *Service) GetPixCharge(ctx context.Context, billing *types.Billing, user *types.User) (*types.PixCharge, error) {
+	if billing.Currency != "BRL" {
+		return nil, fmt.Errorf("pix only supports BRL billings, billing currency is %s", billing.Currency)
+	}
+
+	var payment *types.Payment
+	for i := range billing.Payments {
+		if billing.Payments[i].UserID == user.UserID {
+			payment = &billing.Payments[i]
+		}
+	}
+	if payment == nil {
+		return nil, fmt.Errorf("user is not associated with billing %s", billing.Name)
+	}
+	if payment.AmountDue <= 0 {
+		return nil, fmt.Errorf("user has nothing left to pay in billing %s", billing.Name)
+	}
+
+	receiver := billing.Payer
+	if receiver == nil {
+		var err error
+		receiver, err = s.repository.GetPixReceiver(ctx)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no admin has a pix key registered")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if receiver.PixKey == "" {
+		return nil, fmt.Errorf("billing payer has no pix key registered")
+	}
+	if receiver.UserID == user.UserID {
+		return nil, fmt.Errorf("user is the receiver of billing %s", billing.Name)
+	}
+
+	payload := pix.Payload{
+		Key:    receiver.PixKey,
+		Name:   receiver.TelegramName,
+		City:   receiver.PixCity,
+		Amount: payment.AmountDue,
+		TxID:   billing.Name,
+	}
+
+	return &types.PixCharge{
+		Billing:  billing,
+		Debtor:   payment.UserInfo,
+		Receiver: *receiver,
+		Amount:   payment.AmountDue,
+		Payload:  payload.String(),
+	}, nil
+}
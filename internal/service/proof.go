@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// applyProofs flags the payments with a proof waiting for review
+func applyProofs(billing *types.Billing, proofs []types.PaymentProof) {
+	for i := range billing.Payments {
+		payment := &billing.Payments[i]
+		payment.Proof = nil
+
+		for _, proof := range proofs {
+			if proof.UserID == payment.UserID && proof.Status == types.ProofPendingReview {
+				payment.Proof = &proof
+			}
+		}
+
+		if payment.Proof != nil && !payment.Paid {
+			payment.Status = types.PaymentPendingReview
+		}
+	}
+}
+
+func (s *Service) ListAdmins(ctx context.Context) ([]*types.User, error) {
+	return s.repository.ListAdmins(ctx)
+}
+
+// SubmitPaymentProof stores a receipt sent by the user for review, when no
+// amount is informed the proof covers the whole outstanding balance
+func (s *Service) SubmitPaymentProof(ctx context.Context, proof *types.PaymentProof) (_ *types.PaymentProof, err error) {
+	audit := s.startAudit(ctx, "proof_submit")
+	audit.billing(&types.Billing{ID: proof.BillingID})
+	audit.user(&types.User{UserID: proof.UserID})
+	defer s.finishAudit(ctx, audit, &err)
+
+	if proof.FileID == "" {
+		return nil, fmt.Errorf("missing payment proof file")
+	}
+
+	billing, payment, err := s.findPayment(ctx, &types.PaymentEntry{BillingID: proof.BillingID, UserID: proof.UserID})
+	if err != nil {
+		return nil, err
+	}
+	audit.billing(billing)
+	audit.user(&payment.UserInfo)
+
+	if payment.Proof != nil {
+		return nil, fmt.Errorf("there is already a payment proof waiting for review")
+	}
+
+	if payment.AmountDue == 0 {
+		return nil, fmt.Errorf("there is nothing left to pay")
+	}
+
+	if proof.Amount < 0 {
+		return nil, fmt.Errorf("payment amount must be positive")
+	}
+
+	if proof.Amount == 0 {
+		proof.Amount = payment.AmountDue
+	}
+
+	if proof.Amount > payment.AmountDue {
+		return nil, fmt.Errorf(
+			"amount %s exceeds the remaining balance %s",
+			money.Format(proof.Amount, billing.Currency),
+			money.Format(payment.AmountDue, billing.Currency),
+		)
+	}
+
+	proof.ID, err = uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	proof.Status = types.ProofPendingReview
+	proof.CreatedAt = time.Now()
+
+	if err := s.repository.CreatePaymentProof(ctx, proof); err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// ApprovePaymentProof records the amount of the proof in the ledger
+func (s *Service) ApprovePaymentProof(ctx context.Context, proof *types.PaymentProof, reviewer *types.User) (_ *types.PaymentProof, _ *types.Payment, err error) {
+	audit := s.startAudit(ctx, "proof_approve")
+	defer s.finishAudit(ctx, audit, &err)
+
+	proof, err = s.getPendingProof(ctx, proof)
+	if err != nil {
+		return nil, nil, err
+	}
+	audit.billing(&types.Billing{ID: proof.BillingID})
+	audit.user(&types.User{UserID: proof.UserID})
+	audit.entry.Details = fmt.Sprintf("proof %s", proof.ID)
+
+	note := "payment proof approved"
+	if proof.Note != "" {
+		note = fmt.Sprintf("%s: %s", note, proof.Note)
+	}
+
+	entry := &types.PaymentEntry{
+		BillingID:  proof.BillingID,
+		UserID:     proof.UserID,
+		Amount:     proof.Amount,
+		Note:       note,
+		RecordedBy: reviewer.UserID,
+	}
+	billing, payment, err := s.findPayment(ctx, entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := preparePayment(billing, payment, entry); err != nil {
+		return nil, nil, err
+	}
+	if err := newPaymentEntry(entry); err != nil {
+		return nil, nil, err
+	}
+	audit.entry.Details = fmt.Sprintf("proof %s, amount %s, late fee %s", proof.ID,
+		money.Format(entry.Amount, billing.Currency),
+		money.Format(entry.Fee, billing.Currency),
+	)
+
+	// The review and the ledger entry are saved together, a proof reviewed
+	// meanwhile is not approved again
+	proof.Status = types.ProofApproved
+	proof.ReviewedBy = reviewer.UserID
+	proof.ReviewedAt = time.Now()
+	if err := s.repository.ApprovePaymentProof(ctx, proof, entry); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("payment proof was already reviewed")
+		}
+		return nil, nil, err
+	}
+	s.logProofReview(proof, reviewer)
+
+	return proof, s.appendEntry(billing, payment, *entry), nil
+}
+
+// RejectPaymentProof closes the review keeping the payment unpaid
+func (s *Service) RejectPaymentProof(ctx context.Context, proof *types.PaymentProof, reviewer *types.User, reason string) (_ *types.PaymentProof, err error) {
+	audit := s.startAudit(ctx, "proof_reject")
+	defer s.finishAudit(ctx, audit, &err)
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to reject a payment proof")
+	}
+
+	proof, err = s.getPendingProof(ctx, proof)
+	if err != nil {
+		return nil, err
+	}
+	audit.billing(&types.Billing{ID: proof.BillingID})
+	audit.user(&types.User{UserID: proof.UserID})
+	audit.entry.Details = fmt.Sprintf("proof %s: %s", proof.ID, reason)
+
+	proof.Status = types.ProofRejected
+	proof.Reason = reason
+	if err := s.reviewPaymentProof(ctx, proof, reviewer); err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+func (s *Service) getPendingProof(ctx context.Context, proof *types.PaymentProof) (*types.PaymentProof, error) {
+	if proof.ID == uuid.Nil {
+		return nil, fmt.Errorf("missing payment proof id")
+	}
+
+	proof, err := s.repository.GetPaymentProof(ctx, proof)
+	if err != nil {
+		return nil, err
+	}
+
+	if proof.Status != types.ProofPendingReview {
+		return nil, fmt.Errorf("payment proof was already %s", proof.Status)
+	}
+
+	return proof, nil
+}
+
+func (s *Service) reviewPaymentProof(ctx context.Context, proof *types.PaymentProof, reviewer *types.User) error {
+	proof.ReviewedBy = reviewer.UserID
+	proof.ReviewedAt = time.Now()
+
+	if err := s.repository.UpdatePaymentProof(ctx, proof); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("payment proof was already reviewed")
+		}
+		return err
+	}
+
+	s.logProofReview(proof, reviewer)
+	return nil
+}
+
+func (s *Service) logProofReview(proof *types.PaymentProof, reviewer *types.User) {
+	s.logger.Info("payment proof reviewed",
+		zap.String("ProofID", proof.ID.String()),
+		zap.String("BillingID", proof.BillingID.String()),
+		zap.String("UserID", proof.UserID.String()),
+		zap.String("Status", string(proof.Status)),
+		zap.String("Reason", proof.Reason),
+		zap.String("ReviewedBy", reviewer.UserID.String()),
+	)
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// SetExchangeRate records the rate of the pair, rates without date are
+// effective from now on. Previous rates are kept for older billings
+func (s *Service) SetExchangeRate(ctx context.Context, rate *types.ExchangeRate) (_ *types.ExchangeRate, err error) {
+	audit := s.startAudit(ctx, "rate_set")
+	defer s.finishAudit(ctx, audit, &err)
+
+	if rate.Base, err = money.NormalizeCurrency(rate.Base); err != nil {
+		return nil, err
+	}
+	if rate.Quote, err = money.NormalizeCurrency(rate.Quote); err != nil {
+		return nil, err
+	}
+
+	if rate.Base == rate.Quote {
+		return nil, fmt.Errorf("exchange rates need two different currencies")
+	}
+	if rate.Rate <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate: %g", rate.Rate)
+	}
+
+	rate.CreatedAt = time.Now()
+	if rate.EffectiveAt.IsZero() {
+		rate.EffectiveAt = rate.CreatedAt
+	}
+	audit.entry.Details = fmt.Sprintf("1 %s = %g %s from %s", rate.Base, rate.Rate, rate.Quote, rate.EffectiveAt.Format("2006-01-02"))
+
+	rate.ID, err = uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.CreateExchangeRate(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// ListExchangeRates returns the history of the rates, latest effective first.
+// Empty currencies match every currency
+func (s *Service) ListExchangeRates(ctx context.Context, base, quote string) ([]types.ExchangeRate, error) {
+	var err error
+	if base != "" {
+		if base, err = money.NormalizeCurrency(base); err != nil {
+			return nil, err
+		}
+	}
+	if quote != "" {
+		if quote, err = money.NormalizeCurrency(quote); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repository.ListExchangeRates(ctx, base, quote)
+}
+
+// GroupCurrency returns the settlement currency of the group, groups without
+// members yet settle in the default currency
+func (s *Service) GroupCurrency(ctx context.Context, chatID int64) (string, error) {
+	group, err := s.repository.GetGroup(ctx, chatID)
+	if err == sql.ErrNoRows {
+		return money.DefaultCurrency, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return group.Currency, nil
+}
+
+// SetGroupCurrency changes the currency balances and reports of the group are
+// converted to
+func (s *Service) SetGroupCurrency(ctx context.Context, group *types.Group) (err error) {
+	audit := s.startAudit(ctx, "group_currency")
+	audit.entry.ChatID = group.ChatID
+	defer s.finishAudit(ctx, audit, &err)
+
+	group.Currency, err = money.NormalizeCurrency(group.Currency)
+	if err != nil {
+		return err
+	}
+	audit.entry.Details = group.Currency
+
+	group.CreatedAt = time.Now()
+	if err := s.repository.SaveGroup(ctx, group); err != nil {
+		return err
+	}
+
+	return s.repository.UpdateGroupCurrency(ctx, group)
+}
+
+// exchangeRate returns the rate from one currency to another effective at the
+// date, inverting the rate of the opposite pair when needed. The bool is false
+// when no rate was effective yet
+func (s *Service) exchangeRate(ctx context.Context, from, to string, at time.Time) (float64, bool, error) {
+	if from == to {
+		return 1, true, nil
+	}
+
+	rates, err := s.repository.ListExchangeRates(ctx, from, to)
+	if err != nil {
+		return 0, false, err
+	}
+	if rate, ok := effectiveRate(rates, at); ok {
+		return rate, true, nil
+	}
+
+	rates, err = s.repository.ListExchangeRates(ctx, to, from)
+	if err != nil {
+		return 0, false, err
+	}
+	if rate, ok := effectiveRate(rates, at); ok {
+		return 1 / rate, true, nil
+	}
+
+	return 0, false, nil
+}
+
+// effectiveRate returns the latest rate effective at the date, rates are
+// sorted latest first
+func effectiveRate(rates []types.ExchangeRate, at time.Time) (float64, bool) {
+	for _, rate := range rates {
+		if !rate.EffectiveAt.After(at) {
+			return rate.Rate, true
+		}
+	}
+	return 0, false
+}
+
+// convertBilling fills the value of the billing in the settlement currency of
+// its group with the rate effective when it was created
+func (s *Service) convertBilling(ctx context.Context, billing *types.Billing) error {
+	currency, err := s.GroupCurrency(ctx, billing.ChatID)
+	if err != nil {
+		return err
+	}
+
+	billing.Conversion = nil
+	if currency == billing.Currency {
+		return nil
+	}
+
+	rate, ok, err := s.exchangeRate(ctx, billing.Currency, currency, billing.CreatedAt)
+	if err != nil || !ok {
+		return err
+	}
+
+	billing.Conversion = &types.Conversion{
+		Currency: currency,
+		Value:    money.Convert(billing.Value, billing.Currency, currency, rate),
+		Rate:     rate,
+	}
+	return nil
+}
+
+// settlementAmount converts an amount of the billing to the settlement
+// currency of its group, amounts are kept in the billing currency when there
+// is no exchange rate
+func settlementAmount(billing *types.Billing, amount int64) (int64, string) {
+	if billing.Conversion == nil {
+		return amount, billing.Currency
+	}
+	return money.Convert(amount, billing.Currency, billing.Conversion.Currency, billing.Conversion.Rate), billing.Conversion.Currency
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ParseRecurrence parses a recurrence rule, accepted values are
+// weekly, monthly, yearly and day:<1-31> (monthly on a custom day)
+func ParseRecurrence(rule string) (types.Recurrence, int, error) {
+	rule = strings.ToLower(strings.TrimSpace(rule))
+
+	switch types.Recurrence(rule) {
+	case types.RecurrenceWeekly, types.RecurrenceMonthly, types.RecurrenceYearly:
+		return types.Recurrence(rule), 0, nil
+	}
+
+	if day, ok := strings.CutPrefix(rule, "day:"); ok {
+		value, err := strconv.Atoi(day)
+		if err != nil || value < 1 || value > 31 {
+			return types.RecurrenceNone, 0, fmt.Errorf("invalid day of month: %s", day)
+		}
+		return types.RecurrenceMonthly, value, nil
+	}
+
+	return types.RecurrenceNone, 0, fmt.Errorf("invalid recurrence: %s", rule)
+}
+
+// nextCycleAt returns when the cycle following the one started at from
+// begins, day is the day of the month monthly and yearly cycles start on
+func nextCycleAt(rule types.Recurrence, day int, from time.Time) time.Time {
+	switch rule {
+	case types.RecurrenceWeekly:
+		return from.AddDate(0, 0, 7)
+	case types.RecurrenceMonthly:
+		next := dateInMonth(from.Year(), from.Month(), day, from)
+		if !next.After(from) {
+			next = dateInMonth(from.Year(), from.Month()+1, day, from)
+		}
+		return next
+	case types.RecurrenceYearly:
+		// Clamped dates keep the original day, so a cycle started on Feb 29
+		// moves to Feb 28 and back to Feb 29 on leap years
+		if day == 0 {
+			day = from.Day()
+		}
+		return dateInMonth(from.Year()+1, from.Month(), day, from)
+	}
+
+	return time.Time{}
+}
+
+// dateInMonth builds a date in the given month, clamping the day to the
+// month length so a billing due on the 31st is due on the 30th in April
+func dateInMonth(year int, month time.Month, day int, clock time.Time) time.Time {
+	first := time.Date(year, month, 1, clock.Hour(), clock.Minute(), clock.Second(), 0, clock.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// seriesID returns the identifier of the first billing of a recurring series
+func seriesID(billing *types.Billing) uuid.UUID {
+	if billing.ParentID != uuid.Nil {
+		return billing.ParentID
+	}
+	return billing.ID
+}
+
+// isRecurring reports whether the billing belongs to a recurring series
+func isRecurring(billing *types.Billing) bool {
+	return billing.Recurrence != types.RecurrenceNone || billing.ParentID != uuid.Nil
+}
+
+// OpenBillingCycles opens a new cycle for every recurring billing whose
+// current period is over, catching up on every period missed since then
+func (s *Service) OpenBillingCycles(ctx context.Context, now time.Time) ([]*types.Billing, error) {
+	series, err := s.repository.ListDueRecurringBillings(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	opened := []*types.Billing{}
+	for _, root := range series {
+		for !root.NextCycleAt.After(now) {
+			cycle, err := s.openBillingCycle(ctx, root)
+			if err != nil {
+				s.logger.Error("error opening billing cycle", zap.String("BillingID", root.ID.String()), zap.Error(err))
+				break
+			}
+			opened = append(opened, cycle)
+		}
+	}
+
+	return opened, nil
+}
+
+func (s *Service) openBillingCycle(ctx context.Context, root *types.Billing) (_ *types.Billing, err error) {
+	audit := s.startAudit(ctx, "billing_cycle")
+	audit.billing(root)
+	defer s.finishAudit(ctx, audit, &err)
+
+	latest, err := s.repository.GetLatestBillingCycle(ctx, root.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	cycleID, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	cycle := &types.Billing{
+		ID:          cycleID,
+		Name:        fmt.Sprintf("%s#%d", root.Name, latest.Cycle+1),
+		Value:       latest.Value,
+		Currency:    latest.Currency,
+		PayerID:     latest.PayerID,
+		Category:    latest.Category,
+		Tags:        latest.Tags,
+		LateFee:     latest.LateFee,
+		ChatID:      latest.ChatID,
+		CreatedAt:   time.Now(),
+		ParentID:    root.ID,
+		Cycle:       latest.Cycle + 1,
+		PeriodStart: root.NextCycleAt,
+		Payments:    []types.Payment{},
+	}
+
+	// Keep the due date at the same distance from the period start
+	if !latest.DueAt.IsZero() {
+		cycle.DueAt = cycle.PeriodStart.Add(latest.DueAt.Sub(latest.PeriodStart))
+	}
+
+	// Users associated with the previous cycle must pay the new one too
+	for _, payment := range latest.Payments {
+		cycle.Payments = append(cycle.Payments, types.Payment{
+			BillingID: cycle.ID,
+			UserID:    payment.UserID,
+			Paid:      false,
+			Share:     payment.Share,
+		})
+	}
+
+	root.NextCycleAt = nextCycleAt(root.Recurrence, root.RecurrenceDay, root.NextCycleAt)
+
+	if err := s.repository.CreateBillingCycle(ctx, root, cycle); err != nil {
+		return nil, err
+	}
+	audit.billing(cycle)
+
+	s.logger.Info("Billing cycle opened", zap.String("Name", cycle.Name), zap.Int("Cycle", cycle.Cycle))
+	return cycle, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"misaki/types"
+)
+
+func TestNextCycleAt(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 10, 30, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		rule types.Recurrence
+		day  int
+		from time.Time
+		want time.Time
+	}{
+		{name: "weekly", rule: types.RecurrenceWeekly, from: date(2026, 1, 28), want: date(2026, 2, 4)},
+		{name: "monthly", rule: types.RecurrenceMonthly, day: 10, from: date(2026, 1, 10), want: date(2026, 2, 10)},
+		{name: "monthly before the day", rule: types.RecurrenceMonthly, day: 15, from: date(2026, 1, 10), want: date(2026, 1, 15)},
+		{name: "monthly into february", rule: types.RecurrenceMonthly, day: 31, from: date(2026, 1, 31), want: date(2026, 2, 28)},
+		{name: "monthly into leap february", rule: types.RecurrenceMonthly, day: 31, from: date(2024, 1, 31), want: date(2024, 2, 29)},
+		{name: "monthly back to the day", rule: types.RecurrenceMonthly, day: 31, from: date(2026, 2, 28), want: date(2026, 3, 31)},
+		{name: "monthly into a 30 day month", rule: types.RecurrenceMonthly, day: 31, from: date(2026, 3, 31), want: date(2026, 4, 30)},
+		{name: "monthly across the year", rule: types.RecurrenceMonthly, day: 31, from: date(2026, 12, 31), want: date(2027, 1, 31)},
+		{name: "yearly", rule: types.RecurrenceYearly, day: 15, from: date(2026, 3, 15), want: date(2027, 3, 15)},
+		{name: "yearly without day", rule: types.RecurrenceYearly, from: date(2026, 3, 15), want: date(2027, 3, 15)},
+		{name: "yearly from february 29", rule: types.RecurrenceYearly, day: 29, from: date(2024, 2, 29), want: date(2025, 2, 28)},
+		{name: "yearly back to february 29", rule: types.RecurrenceYearly, day: 29, from: date(2027, 2, 28), want: date(2028, 2, 29)},
+		{name: "yearly month end", rule: types.RecurrenceYearly, day: 31, from: date(2026, 1, 31), want: date(2027, 1, 31)},
+		{name: "none", rule: types.RecurrenceNone, from: date(2026, 1, 31), want: time.Time{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := nextCycleAt(test.rule, test.day, test.from); !got.Equal(test.want) {
+				t.Fatalf("nextCycleAt(%s, %d, %s) = %s, expected %s", test.rule, test.day, test.from, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule    string
+		want    types.Recurrence
+		day     int
+		invalid bool
+	}{
+		{rule: "weekly", want: types.RecurrenceWeekly},
+		{rule: " Monthly ", want: types.RecurrenceMonthly},
+		{rule: "yearly", want: types.RecurrenceYearly},
+		{rule: "day:31", want: types.RecurrenceMonthly, day: 31},
+		{rule: "day:0", invalid: true},
+		{rule: "day:32", invalid: true},
+		{rule: "day:x", invalid: true},
+		{rule: "daily", invalid: true},
+	}
+
+	for _, test := range tests {
+		got, day, err := ParseRecurrence(test.rule)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("ParseRecurrence(%q) = %s, %d, expected error", test.rule, got, day)
+			}
+			continue
+		}
+
+		if err != nil || got != test.want || day != test.day {
+			t.Errorf("ParseRecurrence(%q) = %s, %d, %v, expected %s, %d", test.rule, got, day, err, test.want, test.day)
+		}
+	}
+}
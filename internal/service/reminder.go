@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// ListPendingReminders returns the payments not fully paid whose due date is at most
+// daysBefore days ahead or daysAfter days behind now, skipping users reminded
+// less than cadence ago
+func (s *Service) ListPendingReminders(ctx context.Context, now time.Time, daysBefore, daysAfter int, cadence time.Duration) ([]*types.Reminder, error) {
+	candidates, err := s.repository.ListPaymentsWithDueDate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	billings := map[uuid.UUID]*types.Billing{}
+	groups := map[int64]*types.Group{}
+	reminders := []*types.Reminder{}
+	for _, r := range candidates {
+		windowStart := r.Billing.DueAt.AddDate(0, 0, -daysBefore)
+		windowEnd := r.Billing.DueAt.AddDate(0, 0, daysAfter)
+		if now.Before(windowStart) || now.After(windowEnd) {
+			continue
+		}
+
+		if now.Sub(r.Payment.RemindedAt) < cadence {
+			continue
+		}
+
+		// Load the billing once to know how much each user owes
+		billing, ok := billings[r.Billing.ID]
+		if !ok {
+			billing, err = s.GetBilling(ctx, &types.Billing{ID: r.Billing.ID})
+			if err != nil {
+				return nil, err
+			}
+			billings[r.Billing.ID] = billing
+		}
+
+		r.Billing = *billing
+		for _, payment := range billing.Payments {
+			if payment.UserID == r.Payment.UserID {
+				r.Payment.Amount = payment.Amount
+				r.Payment.Outstanding = payment.Outstanding
+				r.Payment.Penalty = payment.Penalty
+				r.Payment.AmountDue = payment.AmountDue
+				r.Payment.Paid = payment.Paid
+			}
+		}
+
+		if r.Payment.Paid {
+			continue
+		}
+
+		// Billings are only found in their group, the reminder tells where
+		// to pay them
+		if billing.ChatID != 0 {
+			group, ok := groups[billing.ChatID]
+			if !ok {
+				group, err = s.repository.GetGroup(ctx, billing.ChatID)
+				if err != nil && err != sql.ErrNoRows {
+					return nil, err
+				}
+				groups[billing.ChatID] = group
+			}
+			r.Group = group
+		}
+
+		reminders = append(reminders, r)
+	}
+
+	return reminders, nil
+}
+
+func (s *Service) MarkReminded(ctx context.Context, payment *types.Payment) error {
+	payment.RemindedAt = time.Now()
+	return s.repository.MarkReminded(ctx, payment)
+}
+
+func (s *Service) SetUserReminders(ctx context.Context, user *types.User, enabled bool) (err error) {
+	audit := s.startAudit(ctx, "reminders")
+	audit.user(user)
+	audit.entry.Details = fmt.Sprintf("enabled: %t", enabled)
+	defer s.finishAudit(ctx, audit, &err)
+
+	if user.UserID == uuid.Nil && user.TelegramID <= 0 {
+		return fmt.Errorf("missing identifiers to update user")
+	}
+
+	// Unregistered users have nothing to update, sql.ErrNoRows is returned
+	found, err := s.GetUser(ctx, user)
+	if err != nil {
+		return err
+	}
+	audit.user(found)
+
+	found.Reminders = enabled
+	if err := s.repository.UpdateUserReminders(ctx, found); err != nil {
+		return err
+	}
+
+	user.Reminders = enabled
+	return nil
+}
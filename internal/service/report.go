@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// ParsePeriod parses a month in the YYYY-MM format, empty periods are the
+// current month
+func ParsePeriod(period string, now time.Time) (time.Time, error) {
+	if period == "" {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+
+	month, err := time.Parse("2006-01", period)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid period %s, expected: YYYY-MM", period)
+	}
+	return month, nil
+}
+
+// MonthlyReport aggregates the billings of the group created in the month of
+// period, the payments overdue at now are listed. Values are converted to the
+// settlement currency of the group when there is an exchange rate
+func (s *Service) MonthlyReport(ctx context.Context, chatID int64, period, now time.Time) (*types.Report, error) {
+	start := time.Date(period.Year(), period.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	billings, err := s.repository.ListBillings(ctx, types.BillingFilter{ChatID: chatID})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &types.Report{Period: start}
+	totals := map[string]*types.ReportTotal{}
+	type userKey struct {
+		user     uuid.UUID
+		currency string
+	}
+	users := map[userKey]*types.ReportUser{}
+
+	for _, b := range billings {
+		if b.CreatedAt.Before(start) || !b.CreatedAt.Before(end) {
+			continue
+		}
+
+		billing, err := s.GetBilling(ctx, &types.Billing{ID: b.ID})
+		if err != nil {
+			return nil, err
+		}
+		report.Billings++
+
+		value, currency := settlementAmount(billing, billing.Value)
+		total, ok := totals[currency]
+		if !ok {
+			total = &types.ReportTotal{Currency: currency}
+			totals[currency] = total
+		}
+		total.Value += value
+
+		for _, payment := range billing.Payments {
+			amount, _ := settlementAmount(billing, payment.Amount)
+			paid, _ := settlementAmount(billing, payment.PaidAmount)
+			outstanding, _ := settlementAmount(billing, payment.Outstanding)
+
+			total.Paid += paid
+			total.Outstanding += outstanding
+
+			key := userKey{payment.UserID, currency}
+			user, ok := users[key]
+			if !ok {
+				user = &types.ReportUser{User: payment.UserInfo, Currency: currency}
+				users[key] = user
+			}
+			user.Owed += amount
+			user.Paid += paid
+
+			if payment.Outstanding > 0 && !billing.DueAt.IsZero() && now.After(billing.DueAt) {
+				report.Overdue = append(report.Overdue, types.ReportOverdue{
+					Billing:     billing.Name,
+					DueAt:       billing.DueAt,
+					User:        payment.UserInfo,
+					Currency:    currency,
+					Outstanding: outstanding,
+				})
+			}
+		}
+	}
+
+	for _, total := range totals {
+		report.Totals = append(report.Totals, *total)
+	}
+	sort.Slice(report.Totals, func(i, j int) bool {
+		return report.Totals[i].Value > report.Totals[j].Value
+	})
+
+	for _, user := range users {
+		report.Users = append(report.Users, *user)
+	}
+	sort.Slice(report.Users, func(i, j int) bool {
+		a, b := report.Users[i], report.Users[j]
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		if a.Owed != b.Owed {
+			return a.Owed > b.Owed
+		}
+		return a.User.UserID.String() < b.User.UserID.String()
+	})
+
+	sort.Slice(report.Overdue, func(i, j int) bool {
+		return report.Overdue[i].DueAt.Before(report.Overdue[j].DueAt)
+	})
+
+	return report, nil
+}
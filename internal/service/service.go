@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"misaki/internal/money"
 	"misaki/internal/repository"
 	"misaki/types"
 
@@ -26,9 +27,16 @@ func NewService(logger *zap.Logger, repo repository.Repository) *Service {
 	}
 }
 
-func (s *Service) CreateUser(ctx context.Context, user *types.User) (*types.User, error) {
+func (s *Service) CreateUser(ctx context.Context, user *types.User) (_ *types.User, err error) {
+	audit := s.startAudit(ctx, "user_create")
+	audit.user(user)
+	defer s.finishAudit(ctx, audit, &err)
+
 	userFound, err := s.repository.GetUser(ctx, user)
 	if err != sql.ErrNoRows {
+		if userFound != nil && !userFound.DeletedAt.IsZero() {
+			return nil, fmt.Errorf("user was deleted, restore it instead")
+		}
 		if userFound != nil {
 			return nil, fmt.Errorf("user already exist")
 		}
@@ -42,6 +50,7 @@ func (s *Service) CreateUser(ctx context.Context, user *types.User) (*types.User
 	}
 
 	user.UserID = userID
+	user.Reminders = true
 	user.CreatedAt = time.Now()
 
 	if err := s.repository.CreateUser(ctx, user); err != nil {
@@ -55,13 +64,59 @@ func (s *Service) GetUser(ctx context.Context, user *types.User) (*types.User, e
 		return nil, fmt.Errorf("missing identifiers to search user")
 	}
 
-	return s.repository.GetUser(ctx, user)
+	user, err := s.repository.GetUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deleted users are kept only to restore them and their history
+	if !user.DeletedAt.IsZero() {
+		return nil, sql.ErrNoRows
+	}
+
+	return user, nil
 }
 
-func (s *Service) DeleteUser(ctx context.Context, user *types.User) error {
+// DeleteUser soft deletes the user, it can be restored until purged. Users
+// with outstanding shares cannot be deleted, as they still count in the split
+func (s *Service) DeleteUser(ctx context.Context, user *types.User) (err error) {
+	audit := s.startAudit(ctx, "user_delete")
+	audit.user(user)
+	defer s.finishAudit(ctx, audit, &err)
+
 	if user.UserID == uuid.Nil && user.TelegramID <= 0 {
 		return fmt.Errorf("missing identifiers to delete user")
 	}
+
+	user, err = s.GetUser(ctx, user)
+	if err != nil {
+		return err
+	}
+	audit.user(user)
+
+	// Deleted users keep their shares, which are only settled while active
+	billings, err := s.repository.ListBillings(ctx, types.BillingFilter{UserID: user.UserID})
+	if err != nil {
+		return err
+	}
+	for _, b := range billings {
+		billing, err := s.GetBilling(ctx, &types.Billing{ID: b.ID})
+		if err != nil {
+			return err
+		}
+
+		for _, payment := range billing.Payments {
+			if payment.UserID == user.UserID && payment.AmountDue > 0 {
+				return fmt.Errorf(
+					"user owes %s in %s, settle or disassociate it before deleting the user",
+					money.Format(payment.AmountDue, billing.Currency),
+					billing.Name,
+				)
+			}
+		}
+	}
+
+	user.DeletedAt = time.Now()
 	return s.repository.DeleteUser(ctx, user)
 }
 
@@ -79,77 +134,433 @@ func (s *Service) GetBilling(ctx context.Context, billing *types.Billing) (*type
 		return nil, fmt.Errorf("missing billing id")
 	}
 
+	// Billings of other groups are not found, an empty chat searches any group
+	chatID := billing.ChatID
 	billing, err := s.repository.GetBilling(ctx, billing)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(billing.Payments) > 0 {
-		billing.ValuePerUser = billing.Value / float64(len(billing.Payments))
+	if !billing.DeletedAt.IsZero() || (chatID != 0 && billing.ChatID != chatID) {
+		return nil, sql.ErrNoRows
+	}
+
+	splitBilling(billing)
+
+	entries, err := s.repository.ListPaymentEntries(ctx, billing.ID)
+	if err != nil {
+		return nil, err
+	}
+	applyLedger(billing, entries)
+	applyLateFees(billing, time.Now())
+
+	proofs, err := s.repository.ListPaymentProofs(ctx, billing.ID)
+	if err != nil {
+		return nil, err
+	}
+	applyProofs(billing, proofs)
+
+	if billing.PayerID != uuid.Nil {
+		billing.Payer, err = s.repository.GetUser(ctx, &types.User{UserID: billing.PayerID})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Past and current cycles of recurring billings, or every installment
+	if isRecurring(billing) || isInstallment(billing) {
+		billing.Cycles, err = s.repository.ListBillingCycles(ctx, seriesID(billing))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if isInstallment(billing) {
+		billing.InstallmentsPaid, err = s.countPaidInstallments(ctx, seriesID(billing))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	billing.Notes, err = s.repository.ListBillingNotes(ctx, billing.ID, latestNotes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.convertBilling(ctx, billing); err != nil {
+		return nil, err
 	}
 
 	return billing, nil
 }
 
-func (s *Service) ListBillings(ctx context.Context) ([]*types.Billing, error) {
-	return s.repository.ListBillings(ctx)
+func (s *Service) ListBillings(ctx context.Context, filter types.BillingFilter) ([]*types.Billing, error) {
+	var err error
+	if filter.Category != "" {
+		if filter.Category, err = normalizeLabel(filter.Category); err != nil {
+			return nil, err
+		}
+	}
+	if filter.Tag != "" {
+		if filter.Tag, err = normalizeLabel(filter.Tag); err != nil {
+			return nil, err
+		}
+	}
+
+	billings, err := s.repository.ListBillings(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Installments of the same plan share the progress
+	paid := map[uuid.UUID]int{}
+	for _, billing := range billings {
+		if !isInstallment(billing) {
+			continue
+		}
+
+		count, ok := paid[seriesID(billing)]
+		if !ok {
+			count, err = s.countPaidInstallments(ctx, seriesID(billing))
+			if err != nil {
+				return nil, err
+			}
+			paid[seriesID(billing)] = count
+		}
+		billing.InstallmentsPaid = count
+	}
+
+	return billings, nil
+}
+
+// CreateBilling creates the billing and associates the users of its payments
+// in a single transaction
+func (s *Service) CreateBilling(ctx context.Context, billing *types.Billing) (_ *types.Billing, err error) {
+	audit := s.startAudit(ctx, "billing_create")
+	audit.billing(billing)
+	defer s.finishAudit(ctx, audit, &err)
+
+	if err := s.prepareBilling(ctx, billing); err != nil {
+		return nil, err
+	}
+
+	for _, payment := range billing.Payments {
+		if err := s.checkMember(ctx, billing.ChatID, payment.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateShares(billing); err != nil {
+		return nil, err
+	}
+
+	// Every installment of a plan is created at once
+	if isInstallment(billing) {
+		installments, err := planInstallments(billing)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.repository.CreateBillings(ctx, installments); err != nil {
+			return nil, err
+		}
+		return billing, nil
+	}
+
+	// The participants are associated in the same transaction
+	if err := s.repository.CreateBillings(ctx, []*types.Billing{billing}); err != nil {
+		return nil, err
+	}
+
+	return billing, nil
 }
 
-func (s *Service) CreateBilling(ctx context.Context, billing *types.Billing) (*types.Billing, error) {
+// prepareBilling validates a new billing and fills its generated fields
+func (s *Service) prepareBilling(ctx context.Context, billing *types.Billing) error {
+	if err := s.checkBillingName(ctx, billing.ChatID, billing.Name); err != nil {
+		return err
+	}
+
+	if err := s.checkMember(ctx, billing.ChatID, billing.PayerID); err != nil {
+		return err
+	}
+
+	if billing.Value < 0 {
+		return fmt.Errorf("invalid value informed: %d", billing.Value)
+	}
+
+	currency, err := money.NormalizeCurrency(billing.Currency)
+	if err != nil {
+		return err
+	}
+	billing.Currency = currency
+
+	if err := validateInstallments(billing); err != nil {
+		return err
+	}
+
+	if err := validateLateFee(billing.LateFee); err != nil {
+		return err
+	}
+
+	if err := normalizeLabels(billing); err != nil {
+		return err
+	}
+
+	billing.ID, err = uuid.NewV7()
+	if err != nil {
+		return err
+	}
+	billing.CreatedAt = time.Now()
+	billing.Cycle = 1
+	billing.PeriodStart = billing.CreatedAt
+
+	if billing.Recurrence != types.RecurrenceNone {
+		// Plain monthly and yearly billings renew on the day they were created
+		if billing.Recurrence != types.RecurrenceWeekly && billing.RecurrenceDay == 0 {
+			billing.RecurrenceDay = billing.CreatedAt.Day()
+		}
+		billing.NextCycleAt = nextCycleAt(billing.Recurrence, billing.RecurrenceDay, billing.PeriodStart)
+	}
+
+	return nil
+}
+
+// CheckBillingName validates a billing name before the billing is created,
+// so guided flows can ask for another one
+func (s *Service) CheckBillingName(ctx context.Context, chatID int64, name string) error {
+	return s.checkBillingName(ctx, chatID, name)
+}
+
+// checkBillingName validates a billing name and checks no billing of the
+// group uses it
+func (s *Service) checkBillingName(ctx context.Context, chatID int64, name string) error {
 	// Check invalid names
-	if len(billing.Name) == 0 || strings.Contains(billing.Name, " ") {
-		return nil, fmt.Errorf("invalid name informed: %s", billing.Name)
+	// '#' is reserved to name cycles of recurring billings
+	if len(name) == 0 || strings.ContainsAny(name, " #") {
+		return fmt.Errorf("invalid name informed: %s", name)
 	}
 
 	// Check billing with same name exist
-	copyVal := *billing
-	billingFound, err := s.repository.GetBilling(ctx, &copyVal)
+	billingFound, err := s.repository.GetBilling(ctx, &types.Billing{Name: name, ChatID: chatID})
 	if err != sql.ErrNoRows {
+		if billingFound != nil && !billingFound.DeletedAt.IsZero() {
+			return fmt.Errorf("a deleted billing uses the name %s, restore or purge it first", name)
+		}
 		if billingFound != nil {
-			return nil, fmt.Errorf("billing already exist")
+			return fmt.Errorf("billing already exist")
 		}
 
+		return err
+	}
+
+	return nil
+}
+
+// UpdateBilling applies the non nil fields of update to the billing, every
+// changed field is recorded in the billing history on behalf of actor
+func (s *Service) UpdateBilling(ctx context.Context, billing *types.Billing, update *types.BillingUpdate, actor *types.User) (_ *types.Billing, err error) {
+	audit := s.startAudit(ctx, "billing_edit")
+	audit.billing(billing)
+	defer s.finishAudit(ctx, audit, &err)
+
+	billing, err = s.GetBilling(ctx, billing)
+	if err != nil {
 		return nil, err
 	}
+	audit.billing(billing)
+	before := *billing
 
-	billing.ID, err = uuid.NewV7()
+	if update.Name != nil && *update.Name != billing.Name {
+		if err := s.checkBillingName(ctx, billing.ChatID, *update.Name); err != nil {
+			return nil, err
+		}
+		billing.Name = *update.Name
+	}
+
+	if update.Value != nil {
+		if *update.Value < 0 {
+			return nil, fmt.Errorf("invalid value informed: %d", *update.Value)
+		}
+		billing.Value = *update.Value
+
+		// Splits are recalculated from the new value, fixed and percent
+		// shares must still fit on it
+		if err := validateShares(billing); err != nil {
+			return nil, err
+		}
+	}
+
+	if update.DueAt != nil {
+		billing.DueAt = *update.DueAt
+	}
+
+	if update.PayerID != nil {
+		if err := s.checkMember(ctx, billing.ChatID, *update.PayerID); err != nil {
+			return nil, err
+		}
+		billing.PayerID = *update.PayerID
+	}
+
+	if update.Category != nil {
+		billing.Category = *update.Category
+	}
+
+	if update.LateFee != nil {
+		if err := validateLateFee(*update.LateFee); err != nil {
+			return nil, err
+		}
+		billing.LateFee = *update.LateFee
+	}
+
+	if update.Tags != nil {
+		billing.Tags = *update.Tags
+	}
+
+	if err := normalizeLabels(billing); err != nil {
+		return nil, err
+	}
+
+	changes, err := s.billingChanges(ctx, &before, billing, actor)
 	if err != nil {
 		return nil, err
 	}
-	billing.CreatedAt = time.Now()
 
-	if err := s.repository.CreateBilling(ctx, billing); err != nil {
+	if err := s.repository.UpdateBilling(ctx, billing, changes); err != nil {
 		return nil, err
 	}
 
-	return billing, nil
+	return s.GetBilling(ctx, &types.Billing{ID: billing.ID})
 }
 
-func (s *Service) DeleteBilling(ctx context.Context, billing *types.Billing) error {
+// DeleteBilling soft deletes the billing, it can be restored until purged
+func (s *Service) DeleteBilling(ctx context.Context, billing *types.Billing) (err error) {
+	audit := s.startAudit(ctx, "billing_delete")
+	audit.billing(billing)
+	defer s.finishAudit(ctx, audit, &err)
+
 	if billing.ID == uuid.Nil && billing.Name == "" {
 		return fmt.Errorf("missing identifiers to delete user")
 	}
+
+	billing, err = s.GetBilling(ctx, billing)
+	if err != nil {
+		return err
+	}
+	audit.billing(billing)
+
+	billing.DeletedAt = time.Now()
 	return s.repository.DeleteBilling(ctx, billing)
 }
 
-func (s *Service) ChangePaymentAssociation(ctx context.Context, payment *types.Payment, assoaciate bool) error {
+// ChangePaymentAssociation associates or disassociates the user with the
+// billing, users of installment plans are associated with every installment
+func (s *Service) ChangePaymentAssociation(ctx context.Context, payment *types.Payment, assoaciate bool) (err error) {
+	action := "payment_disassociate"
 	if assoaciate {
-		payment.Paid = false
-		return s.repository.AssociatePayment(ctx, payment)
+		action = "payment_associate"
+	}
+	audit := s.startAudit(ctx, action)
+	audit.billing(&types.Billing{ID: payment.BillingID})
+	audit.user(&types.User{UserID: payment.UserID, TelegramName: payment.UserInfo.TelegramName})
+	defer s.finishAudit(ctx, audit, &err)
+
+	billing, err := s.GetBilling(ctx, &types.Billing{ID: payment.BillingID})
+	if err != nil {
+		return err
+	}
+	audit.billing(billing)
+
+	billings := []*types.Billing{billing}
+	if isInstallment(billing) {
+		billings, err = s.listInstallments(ctx, billing)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !assoaciate {
+		disassociate := make([]*types.Payment, 0, len(billings))
+		for _, billing := range billings {
+			// The ledger of the user is deleted with the association
+			for _, associated := range billing.Payments {
+				if associated.UserID == payment.UserID && (associated.PaidAmount != 0 || associated.Penalty.Paid != 0) {
+					return fmt.Errorf("user has payments recorded in %s, revert them with /billing_unpay_admin first", billing.Name)
+				}
+			}
+
+			change := *payment
+			change.BillingID = billing.ID
+			disassociate = append(disassociate, &change)
+		}
+		return s.repository.DisassociatePayments(ctx, disassociate)
 	}
-	return s.repository.DisassociatePayment(ctx, payment)
+
+	if err := s.checkMember(ctx, billing.ChatID, payment.UserID); err != nil {
+		return err
+	}
+
+	// Shares are validated in every billing before changing any of them
+	exists := make([]bool, len(billings))
+	for i, billing := range billings {
+		// Associating an user again only changes its share
+		for j := range billing.Payments {
+			if billing.Payments[j].UserID == payment.UserID {
+				billing.Payments[j].Share = payment.Share
+				exists[i] = true
+			}
+		}
+		if !exists[i] {
+			billing.Payments = append(billing.Payments, *payment)
+		}
+
+		if err := validateShares(billing); err != nil {
+			return err
+		}
+	}
+
+	// Every installment is changed or none is
+	associate, update := []*types.Payment{}, []*types.Payment{}
+	for i, billing := range billings {
+		change := *payment
+		change.BillingID = billing.ID
+
+		if exists[i] {
+			update = append(update, &change)
+		} else {
+			change.Paid = false
+			associate = append(associate, &change)
+		}
+	}
+
+	return s.repository.SavePaymentAssociations(ctx, associate, update)
 }
 
-func (s *Service) ChangePaymentStatus(ctx context.Context, payment *types.Payment) error {
-	if payment.BillingID == uuid.Nil || payment.UserID == uuid.Nil {
-		return fmt.Errorf("missing billing or user identifier")
+// JoinBilling associates a member of the group of the billing with the default
+// share, members cannot join a billing twice so their share is only changed
+// by admins
+func (s *Service) JoinBilling(ctx context.Context, billing *types.Billing, user *types.User) (_ *types.Billing, err error) {
+	billing, err = s.GetBilling(ctx, billing)
+	if err != nil {
+		return nil, err
 	}
 
-	if payment.Paid {
-		payment.PaidAt = time.Now()
+	if err := s.checkMember(ctx, billing.ChatID, user.UserID); err != nil {
+		return nil, err
 	}
 
-	return s.repository.ChangePaymentStatus(ctx, payment)
+	for _, payment := range billing.Payments {
+		if payment.UserID == user.UserID {
+			return nil, fmt.Errorf("user already shares this billing")
+		}
+	}
+
+	payment := &types.Payment{BillingID: billing.ID, UserID: user.UserID, UserInfo: *user}
+	if err := s.ChangePaymentAssociation(ctx, payment, true); err != nil {
+		return nil, err
+	}
+
+	return billing, nil
 }
 
 func (s *Service) PaymentAssociationExist(ctx context.Context, payment *types.Payment) (bool, error) {
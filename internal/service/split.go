@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"misaki/internal/money"
+	"misaki/types"
+)
+
+// ParseShare parses a share argument, accepted formats are
+// <N>% (percentage), fixed:<value> (fixed amount in the billing currency)
+// and weight:<N>
+func ParseShare(value, currency string) (types.Share, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	var share types.Share
+	var raw string
+	switch {
+	case strings.HasSuffix(value, "%"):
+		share.Type, raw = types.SharePercent, strings.TrimSuffix(value, "%")
+	case strings.HasPrefix(value, "fixed:"):
+		share.Type, raw = types.ShareFixed, strings.TrimPrefix(value, "fixed:")
+	case strings.HasPrefix(value, "weight:"):
+		share.Type, raw = types.ShareWeight, strings.TrimPrefix(value, "weight:")
+	default:
+		return share, fmt.Errorf("invalid share %s, expected: <N>%%, fixed:<value> or weight:<N>", value)
+	}
+
+	if share.Type == types.ShareFixed {
+		amount, err := money.Parse(raw, currency)
+		if err != nil || amount <= 0 {
+			return share, fmt.Errorf("invalid share value: %s", raw)
+		}
+		share.Amount = amount
+		return share, nil
+	}
+
+	number, err := strconv.ParseFloat(raw, 64)
+	if err != nil || number <= 0 {
+		return share, fmt.Errorf("invalid share value: %s", raw)
+	}
+
+	if share.Type == types.SharePercent && number > 100 {
+		return share, fmt.Errorf("percentage share cannot be greater than 100%%")
+	}
+
+	share.Value = number
+	return share, nil
+}
+
+// splitBilling computes the amount owed by each associated user. Fixed
+// amounts and percentages are taken first and the remaining value is split
+// among the other users proportionally to their weight, whatever could not
+// be assigned (or was assigned in excess, when negative) is left Unallocated.
+// Cents are distributed with the largest remainder method, so amounts always
+// sum up to the billing value
+func splitBilling(billing *types.Billing) {
+	billing.Unallocated = 0
+
+	remaining := float64(billing.Value)
+	weights := 0.0
+	for _, payment := range billing.Payments {
+		switch payment.Share.Type {
+		case types.ShareFixed:
+			remaining -= float64(payment.Share.Amount)
+		case types.SharePercent:
+			remaining -= float64(billing.Value) * payment.Share.Value / 100
+		case types.ShareWeight:
+			weights += payment.Share.Value
+		default:
+			weights++
+		}
+	}
+
+	shared := max(remaining, 0)
+
+	// Exact (fractional) amount owed by each user besides fixed amounts
+	exact := make([]float64, len(billing.Payments))
+	variable := int64(billing.Value)
+	total := 0.0
+	for i, payment := range billing.Payments {
+		switch payment.Share.Type {
+		case types.ShareFixed:
+			variable -= payment.Share.Amount
+			continue
+		case types.SharePercent:
+			exact[i] = float64(billing.Value) * payment.Share.Value / 100
+		case types.ShareWeight:
+			exact[i] = shared * payment.Share.Value / weights
+		default:
+			exact[i] = shared / weights
+		}
+		total += exact[i]
+	}
+
+	// Without equal or weighted shares nobody absorbs the remaining value
+	target := int64(math.Round(total))
+	if weights > 0 && remaining >= 0 {
+		target = max(variable, 0)
+	}
+
+	parts := money.Allocate(target, exact)
+	for i := range billing.Payments {
+		payment := &billing.Payments[i]
+		if payment.Share.Type == types.ShareFixed {
+			payment.Amount = payment.Share.Amount
+			continue
+		}
+		payment.Amount = parts[i]
+	}
+
+	billing.Unallocated = billing.Value
+	for _, payment := range billing.Payments {
+		billing.Unallocated -= payment.Amount
+	}
+}
+
+// validateShares checks the shares of a billing sum up to its value. Equal and
+// weighted shares take whatever fixed amounts and percentages leave, so only
+// billings without them can be under allocated
+func validateShares(billing *types.Billing) error {
+	splitBilling(billing)
+	if billing.Unallocated < 0 {
+		return fmt.Errorf("shares exceed the billing value by %s", money.Format(-billing.Unallocated, billing.Currency))
+	}
+	if billing.Unallocated > 0 && len(billing.Payments) > 0 {
+		return fmt.Errorf("shares leave %s of the billing value unallocated, add an user with an equal or weight share to take the rest", money.Format(billing.Unallocated, billing.Currency))
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"misaki/types"
+)
+
+func TestSplitBilling(t *testing.T) {
+	equal := types.Share{}
+	percent := func(value float64) types.Share { return types.Share{Type: types.SharePercent, Value: value} }
+	fixed := func(amount int64) types.Share { return types.Share{Type: types.ShareFixed, Amount: amount} }
+	weight := func(value float64) types.Share { return types.Share{Type: types.ShareWeight, Value: value} }
+
+	tests := []struct {
+		name        string
+		value       int64
+		shares      []types.Share
+		amounts     []int64
+		unallocated int64
+		invalid     bool
+	}{
+		{
+			name:    "equal shares with remainder",
+			value:   10000,
+			shares:  []types.Share{equal, equal, equal},
+			amounts: []int64{3334, 3333, 3333},
+		},
+		{
+			name:    "weights",
+			value:   999,
+			shares:  []types.Share{weight(1), weight(1), weight(2)},
+			amounts: []int64{250, 250, 499},
+		},
+		{
+			name:    "mixed percent, fixed and weight",
+			value:   10000,
+			shares:  []types.Share{percent(40), equal, weight(2), fixed(1000)},
+			amounts: []int64{4000, 1667, 3333, 1000},
+		},
+		{
+			name:    "percent and fixed filling the value",
+			value:   10000,
+			shares:  []types.Share{percent(50), fixed(5000)},
+			amounts: []int64{5000, 5000},
+		},
+		{
+			name:    "equal share takes what fixed leaves",
+			value:   10000,
+			shares:  []types.Share{fixed(9000), equal},
+			amounts: []int64{9000, 1000},
+		},
+		{
+			name:        "under allocated",
+			value:       10000,
+			shares:      []types.Share{percent(30), fixed(2000)},
+			amounts:     []int64{3000, 2000},
+			unallocated: 5000,
+			invalid:     true,
+		},
+		{
+			name:        "over allocated by percentages",
+			value:       10000,
+			shares:      []types.Share{percent(60), percent(50)},
+			amounts:     []int64{6000, 5000},
+			unallocated: -1000,
+			invalid:     true,
+		},
+		{
+			name:        "over allocated leaves nothing to equal shares",
+			value:       10000,
+			shares:      []types.Share{percent(80), fixed(3000), equal},
+			amounts:     []int64{8000, 3000, 0},
+			unallocated: -1000,
+			invalid:     true,
+		},
+		{
+			name:        "no users",
+			value:       10000,
+			shares:      []types.Share{},
+			amounts:     []int64{},
+			unallocated: 10000,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			billing := &types.Billing{Value: test.value, Currency: "BRL"}
+			for _, share := range test.shares {
+				billing.Payments = append(billing.Payments, types.Payment{Share: share})
+			}
+
+			err := validateShares(billing)
+
+			amounts := []int64{}
+			for _, payment := range billing.Payments {
+				amounts = append(amounts, payment.Amount)
+			}
+			if !slices.Equal(amounts, test.amounts) {
+				t.Fatalf("amounts %v, expected %v", amounts, test.amounts)
+			}
+			if billing.Unallocated != test.unallocated {
+				t.Fatalf("unallocated %d, expected %d", billing.Unallocated, test.unallocated)
+			}
+			if (err != nil) != test.invalid {
+				t.Fatalf("error %v, expected invalid %t", err, test.invalid)
+			}
+		})
+	}
+}
+
+func TestParseShare(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    types.Share
+		invalid bool
+	}{
+		{value: "25%", want: types.Share{Type: types.SharePercent, Value: 25}},
+		{value: "12.5%", want: types.Share{Type: types.SharePercent, Value: 12.5}},
+		{value: "fixed:10,50", want: types.Share{Type: types.ShareFixed, Amount: 1050}},
+		{value: "WEIGHT:2", want: types.Share{Type: types.ShareWeight, Value: 2}},
+		{value: "101%", invalid: true},
+		{value: "0%", invalid: true},
+		{value: "fixed:-1", invalid: true},
+		{value: "fixed:1.555", invalid: true},
+		{value: "weight:x", invalid: true},
+		{value: "half", invalid: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseShare(test.value, "BRL")
+		if test.invalid {
+			if err == nil {
+				t.Errorf("ParseShare(%q) = %+v, expected error", test.value, got)
+			}
+			continue
+		}
+
+		if err != nil || got != test.want {
+			t.Errorf("ParseShare(%q) = %+v, %v, expected %+v", test.value, got, err, test.want)
+		}
+	}
+}
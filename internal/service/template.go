@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"misaki/internal/money"
+	"misaki/types"
+
+	"github.com/google/uuid"
+)
+
+// CreateTemplate validates the defaults of the template and checks its
+// participants are members of the group and their shares fit in the value
+func (s *Service) CreateTemplate(ctx context.Context, template *types.Template) (_ *types.Template, err error) {
+	audit := s.startAudit(ctx, "template_create")
+	audit.entry.Details = fmt.Sprintf("template %s", template.Name)
+	defer s.finishAudit(ctx, audit, &err)
+
+	// Names follow the billing rules since they prefix the billings created
+	if len(template.Name) == 0 || strings.ContainsAny(template.Name, " #") {
+		return nil, fmt.Errorf("invalid name informed: %s", template.Name)
+	}
+
+	_, err = s.repository.GetTemplate(ctx, &types.Template{Name: template.Name, ChatID: template.ChatID})
+	if err != sql.ErrNoRows {
+		if err == nil {
+			return nil, fmt.Errorf("template already exist")
+		}
+		return nil, err
+	}
+
+	if template.Value < 0 {
+		return nil, fmt.Errorf("invalid value informed: %d", template.Value)
+	}
+
+	template.Currency, err = money.NormalizeCurrency(template.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if template.Category != "" {
+		if template.Category, err = normalizeLabel(template.Category); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.checkMember(ctx, template.ChatID, template.PayerID); err != nil {
+		return nil, err
+	}
+
+	if len(template.Users) == 0 {
+		return nil, fmt.Errorf("templates need at least one user")
+	}
+
+	users := map[uuid.UUID]bool{}
+	for _, user := range template.Users {
+		if users[user.UserID] {
+			return nil, fmt.Errorf("user %s informed twice", user.UserInfo.TelegramName)
+		}
+		users[user.UserID] = true
+
+		if err := s.checkMember(ctx, template.ChatID, user.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateShares(templateBilling(template, template.Value)); err != nil {
+		return nil, err
+	}
+
+	template.ID, err = uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	template.CreatedAt = time.Now()
+
+	if err := s.repository.CreateTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// GetTemplate searches the template by ID or by name in its group
+func (s *Service) GetTemplate(ctx context.Context, template *types.Template) (*types.Template, error) {
+	if template.ID == uuid.Nil && template.Name == "" {
+		return nil, fmt.Errorf("missing template identifier")
+	}
+
+	chatID := template.ChatID
+	template, err := s.repository.GetTemplate(ctx, template)
+	if err != nil {
+		return nil, err
+	}
+
+	if chatID != 0 && template.ChatID != chatID {
+		return nil, sql.ErrNoRows
+	}
+
+	return template, nil
+}
+
+func (s *Service) ListTemplates(ctx context.Context, chatID int64) ([]*types.Template, error) {
+	return s.repository.ListTemplates(ctx, chatID)
+}
+
+// CreateBillingFromTemplate creates a billing with the defaults of the
+// template and associates its participants in a single transaction, a zero
+// value keeps the template value. Billings are named after the template and
+// the current month, followed by a counter when the name is taken
+func (s *Service) CreateBillingFromTemplate(ctx context.Context, template *types.Template, value int64) (_ *types.Billing, err error) {
+	audit := s.startAudit(ctx, "billing_from_template")
+	audit.entry.Details = fmt.Sprintf("template %s", template.Name)
+	defer s.finishAudit(ctx, audit, &err)
+
+	template, err = s.GetTemplate(ctx, template)
+	if err != nil {
+		return nil, err
+	}
+	audit.entry.Details = fmt.Sprintf("template %s", template.Name)
+
+	if value == 0 {
+		value = template.Value
+	}
+
+	name, err := s.templateBillingName(ctx, template, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	billing := templateBilling(template, value)
+	billing.Name = name
+	audit.billing(billing)
+
+	if err := s.prepareBilling(ctx, billing); err != nil {
+		return nil, err
+	}
+
+	// Participants may have left the group since the template was created
+	for _, payment := range billing.Payments {
+		if err := s.checkMember(ctx, billing.ChatID, payment.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateShares(billing); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.CreateBillings(ctx, []*types.Billing{billing}); err != nil {
+		return nil, err
+	}
+
+	return s.GetBilling(ctx, &types.Billing{ID: billing.ID})
+}
+
+// templateBilling builds a billing with the defaults of the template
+func templateBilling(template *types.Template, value int64) *types.Billing {
+	billing := &types.Billing{
+		Value:    value,
+		Currency: template.Currency,
+		Category: template.Category,
+		PayerID:  template.PayerID,
+		ChatID:   template.ChatID,
+		Payments: []types.Payment{},
+	}
+
+	for _, user := range template.Users {
+		billing.Payments = append(billing.Payments, types.Payment{
+			UserID:   user.UserID,
+			UserInfo: user.UserInfo,
+			Share:    user.Share,
+		})
+	}
+
+	return billing
+}
+
+// templateBillingName returns the first free name for a billing of the
+// template, deleted billings keep their names until purged
+func (s *Service) templateBillingName(ctx context.Context, template *types.Template, now time.Time) (string, error) {
+	base := fmt.Sprintf("%s-%s", template.Name, now.Format("2006-01"))
+
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		_, err := s.repository.GetBilling(ctx, &types.Billing{Name: name, ChatID: template.ChatID})
+		if err == sql.ErrNoRows {
+			return name, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"misaki/config"
+	"misaki/internal/cli"
 	"misaki/internal/controller"
 	"misaki/internal/controller/telegram"
 	"misaki/internal/repository"
@@ -12,6 +16,14 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := cli.Export(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	fx.New(
 		fx.Provide(
 			config.NewConfig,
@@ -0,0 +1,12 @@
+-- Recurring billings: every cycle is stored as its own billing row pointing
+-- to the first billing of the series (id_parent), which holds the rule
+ALTER TABLE billings ADD COLUMN id_parent TEXT REFERENCES billings(id) ON DELETE CASCADE;
+ALTER TABLE billings ADD COLUMN cycle INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE billings ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
+ALTER TABLE billings ADD COLUMN recurrence_day INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE billings ADD COLUMN period_start DATETIME NOT NULL DEFAULT '0001-01-01 00:00:00+00:00';
+ALTER TABLE billings ADD COLUMN next_cycle_at DATETIME NOT NULL DEFAULT '0001-01-01 00:00:00+00:00';
+
+UPDATE billings SET period_start = created_at WHERE created_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_billings_parent ON billings(id_parent);
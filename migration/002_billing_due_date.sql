@@ -0,0 +1,4 @@
+-- Due dates and payment reminders
+ALTER TABLE billings ADD COLUMN due_at DATETIME NOT NULL DEFAULT '0001-01-01 00:00:00+00:00';
+ALTER TABLE billing_user ADD COLUMN reminded_at DATETIME NOT NULL DEFAULT '0001-01-01 00:00:00+00:00';
+ALTER TABLE users ADD COLUMN reminders BOOLEAN NOT NULL DEFAULT 1;
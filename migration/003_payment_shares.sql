@@ -0,0 +1,3 @@
+-- Custom split rules for each user associated with a billing
+ALTER TABLE billing_user ADD COLUMN share_type TEXT NOT NULL DEFAULT '';
+ALTER TABLE billing_user ADD COLUMN share_value FLOAT NOT NULL DEFAULT 0;
@@ -0,0 +1,47 @@
+-- Ledger of payments made by each user associated with a billing, the paid
+-- flag of billing_user is replaced by the sum of the entries. Associations
+-- with entries cannot be deleted so the history is not lost by accident
+CREATE TABLE IF NOT EXISTS payment_entries (
+  id          TEXT PRIMARY KEY,
+  id_billing  TEXT NOT NULL,
+  id_user     TEXT NOT NULL,
+  amount      FLOAT NOT NULL,
+  note        TEXT NOT NULL DEFAULT '',
+  recorded_by TEXT,
+  created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (id_billing, id_user) REFERENCES billing_user(id_billing, id_user) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_entries_billing ON payment_entries(id_billing, id_user);
+
+-- Payments flagged as paid become a single entry with the amount owed
+INSERT INTO payment_entries (id, id_billing, id_user, amount, note, recorded_by, created_at)
+SELECT
+  lower(hex(randomblob(16))),
+  bu.id_billing,
+  bu.id_user,
+  CASE bu.share_type
+    WHEN 'fixed' THEN bu.share_value
+    WHEN 'percent' THEN b.value * bu.share_value / 100
+    ELSE MAX(b.value - (
+        SELECT COALESCE(SUM(CASE o.share_type
+          WHEN 'fixed' THEN o.share_value
+          WHEN 'percent' THEN b.value * o.share_value / 100
+          ELSE 0 END), 0)
+        FROM billing_user AS o WHERE o.id_billing = bu.id_billing
+      ), 0)
+      * (CASE bu.share_type WHEN 'weight' THEN bu.share_value ELSE 1 END)
+      / (
+        SELECT SUM(CASE o.share_type WHEN 'weight' THEN o.share_value WHEN '' THEN 1 ELSE 0 END)
+        FROM billing_user AS o WHERE o.id_billing = bu.id_billing
+      )
+  END,
+  'migrated from paid status',
+  bu.id_user,
+  COALESCE(bu.paid_at, CURRENT_TIMESTAMP)
+FROM billing_user AS bu
+INNER JOIN billings AS b
+ON bu.id_billing = b.id
+WHERE bu.paid = 1;
+
+UPDATE billing_user SET paid = 0 WHERE paid = 1;
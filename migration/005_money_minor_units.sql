@@ -0,0 +1,28 @@
+-- Money is stored as integer amounts of the currency minor unit, the legacy
+-- float columns are converted assuming values in BRL (2 decimal places)
+ALTER TABLE billings ADD COLUMN amount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE billings ADD COLUMN currency TEXT NOT NULL DEFAULT 'BRL';
+UPDATE billings SET amount = CAST(ROUND(COALESCE(value, 0) * 100) AS INTEGER);
+
+ALTER TABLE billing_user ADD COLUMN share_amount INTEGER NOT NULL DEFAULT 0;
+UPDATE billing_user SET share_amount = CAST(ROUND(share_value * 100) AS INTEGER), share_value = 0
+WHERE share_type = 'fixed';
+
+CREATE TABLE payment_entries_new (
+  id          TEXT PRIMARY KEY,
+  id_billing  TEXT NOT NULL,
+  id_user     TEXT NOT NULL,
+  amount      INTEGER NOT NULL,
+  note        TEXT NOT NULL DEFAULT '',
+  recorded_by TEXT,
+  created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (id_billing, id_user) REFERENCES billing_user(id_billing, id_user) ON DELETE RESTRICT
+);
+
+INSERT INTO payment_entries_new (id, id_billing, id_user, amount, note, recorded_by, created_at)
+SELECT id, id_billing, id_user, CAST(ROUND(amount * 100) AS INTEGER), note, recorded_by, created_at
+FROM payment_entries;
+
+DROP TABLE payment_entries;
+ALTER TABLE payment_entries_new RENAME TO payment_entries;
+CREATE INDEX IF NOT EXISTS idx_payment_entries_billing ON payment_entries(id_billing, id_user);
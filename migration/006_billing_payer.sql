@@ -0,0 +1,2 @@
+-- User who fronted the money of a billing and must receive the payments
+ALTER TABLE billings ADD COLUMN id_payer TEXT REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+-- PIX key used by admins to receive payments
+ALTER TABLE users ADD COLUMN pix_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN pix_city TEXT NOT NULL DEFAULT '';
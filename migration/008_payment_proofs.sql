@@ -0,0 +1,19 @@
+-- Receipts sent by users to prove a payment, reviewed by admins before the
+-- amount is recorded in the ledger
+CREATE TABLE IF NOT EXISTS payment_proofs (
+  id          TEXT PRIMARY KEY,
+  id_billing  TEXT NOT NULL,
+  id_user     TEXT NOT NULL,
+  amount      INTEGER NOT NULL,
+  note        TEXT NOT NULL DEFAULT '',
+  file_id     TEXT NOT NULL,
+  file_type   TEXT NOT NULL,
+  status      TEXT NOT NULL DEFAULT 'pending_review',
+  reason      TEXT NOT NULL DEFAULT '',
+  reviewed_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+  reviewed_at DATETIME NOT NULL DEFAULT '0001-01-01 00:00:00+00:00',
+  created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (id_billing, id_user) REFERENCES billing_user(id_billing, id_user) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_proofs_billing ON payment_proofs(id_billing, id_user);
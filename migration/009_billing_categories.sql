@@ -0,0 +1,19 @@
+-- Categories group billings (utilities, subscriptions, groceries), tags are
+-- free-form labels, a billing has one category and many tags
+CREATE TABLE IF NOT EXISTS categories (
+  name       TEXT PRIMARY KEY,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE billings ADD COLUMN category TEXT REFERENCES categories(name) ON UPDATE CASCADE ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_billings_category ON billings(category);
+
+CREATE TABLE IF NOT EXISTS billing_tags (
+  id_billing TEXT NOT NULL,
+  tag        TEXT NOT NULL,
+  PRIMARY KEY (id_billing, tag),
+  FOREIGN KEY (id_billing) REFERENCES billings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_billing_tags_tag ON billing_tags(tag);
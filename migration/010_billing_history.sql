@@ -0,0 +1,13 @@
+-- Every change made to a billing field with the values formatted as text
+CREATE TABLE IF NOT EXISTS billing_history (
+  id         TEXT PRIMARY KEY,
+  id_billing TEXT NOT NULL,
+  field      TEXT NOT NULL,
+  old_value  TEXT NOT NULL DEFAULT '',
+  new_value  TEXT NOT NULL DEFAULT '',
+  id_user    TEXT REFERENCES users(id) ON DELETE SET NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (id_billing) REFERENCES billings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_billing_history_billing ON billing_history(id_billing, created_at);
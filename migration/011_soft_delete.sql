@@ -0,0 +1,7 @@
+-- Deleted billings and users are kept until purged so the payment history
+-- is not lost, a NULL deleted_at marks an active row
+ALTER TABLE users ADD COLUMN deleted_at DATETIME;
+ALTER TABLE billings ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted ON users(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_billings_deleted ON billings(deleted_at);
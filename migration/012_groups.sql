@@ -0,0 +1,57 @@
+-- Billings belong to the Telegram chat (group) they were created in and users
+-- are members of every group they joined. Billings created before groups
+-- existed belong to the chat 0 until an admin claims them in a real chat
+CREATE TABLE IF NOT EXISTS groups (
+  chat_id    INTEGER PRIMARY KEY,
+  title      TEXT NOT NULL DEFAULT '',
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS group_members (
+  chat_id    INTEGER NOT NULL,
+  id_user    TEXT NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (chat_id, id_user),
+  FOREIGN KEY (chat_id) REFERENCES groups(chat_id) ON DELETE CASCADE,
+  FOREIGN KEY (id_user) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO groups (chat_id, title) VALUES (0, 'legacy');
+INSERT INTO group_members (chat_id, id_user) SELECT 0, id FROM users;
+
+-- Billing names are unique per group, the table is rebuilt to replace the
+-- global unique constraint
+CREATE TABLE billings_new (
+  id             TEXT PRIMARY KEY,
+  chat_id        INTEGER NOT NULL DEFAULT 0 REFERENCES groups(chat_id) ON DELETE CASCADE,
+  name           TEXT NOT NULL,
+  value          FLOAT,
+  created_at     DATETIME DEFAULT CURRENT_TIMESTAMP,
+  id_parent      TEXT REFERENCES billings(id) ON DELETE CASCADE,
+  cycle          INTEGER NOT NULL DEFAULT 1,
+  recurrence     TEXT NOT NULL DEFAULT '',
+  recurrence_day INTEGER NOT NULL DEFAULT 0,
+  period_start   DATETIME NOT NULL DEFAULT '0001-01-01 00:00:00+00:00',
+  next_cycle_at  DATETIME NOT NULL DEFAULT '0001-01-01 00:00:00+00:00',
+  due_at         DATETIME NOT NULL DEFAULT '0001-01-01 00:00:00+00:00',
+  amount         INTEGER NOT NULL DEFAULT 0,
+  currency       TEXT NOT NULL DEFAULT 'BRL',
+  id_payer       TEXT REFERENCES users(id) ON DELETE SET NULL,
+  category       TEXT REFERENCES categories(name) ON UPDATE CASCADE ON DELETE SET NULL,
+  deleted_at     DATETIME,
+  UNIQUE (chat_id, name)
+);
+
+INSERT INTO billings_new (id, chat_id, name, value, created_at, id_parent, cycle, recurrence, recurrence_day, period_start,
+                          next_cycle_at, due_at, amount, currency, id_payer, category, deleted_at)
+SELECT id, 0, name, value, created_at, id_parent, cycle, recurrence, recurrence_day, period_start,
+       next_cycle_at, due_at, amount, currency, id_payer, category, deleted_at
+FROM billings;
+
+DROP TABLE billings;
+ALTER TABLE billings_new RENAME TO billings;
+
+CREATE INDEX IF NOT EXISTS idx_billings_parent ON billings(id_parent);
+CREATE INDEX IF NOT EXISTS idx_billings_category ON billings(category);
+CREATE INDEX IF NOT EXISTS idx_billings_deleted ON billings(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_billings_chat ON billings(chat_id);
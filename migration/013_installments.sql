@@ -0,0 +1,4 @@
+-- Installment plans are stored like recurring billings: every installment is
+-- its own billing row pointing to the first one (id_parent), numbered by
+-- cycle, and installments holds how many installments the plan has
+ALTER TABLE billings ADD COLUMN installments INTEGER NOT NULL DEFAULT 0;
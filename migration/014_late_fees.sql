@@ -0,0 +1,8 @@
+-- Late fee policy of the billing, a flat fee plus a daily interest rate in
+-- percent of the share, limited by a cap (zero means no cap)
+ALTER TABLE billings ADD COLUMN late_fee_flat INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE billings ADD COLUMN late_fee_rate REAL NOT NULL DEFAULT 0;
+ALTER TABLE billings ADD COLUMN late_fee_cap INTEGER NOT NULL DEFAULT 0;
+
+-- Part of the payment entry that paid late fees instead of the share
+ALTER TABLE payment_entries ADD COLUMN fee INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,25 @@
+-- Templates keep the defaults of billings created over and over with the same
+-- participants, template names are unique per group
+CREATE TABLE IF NOT EXISTS templates (
+  id         TEXT PRIMARY KEY,
+  chat_id    INTEGER NOT NULL DEFAULT 0,
+  name       TEXT NOT NULL,
+  amount     INTEGER NOT NULL DEFAULT 0,
+  currency   TEXT NOT NULL DEFAULT 'BRL',
+  category   TEXT NOT NULL DEFAULT '',
+  id_payer   TEXT,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (chat_id, name),
+  FOREIGN KEY (id_payer) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS template_users (
+  id_template  TEXT NOT NULL,
+  id_user      TEXT NOT NULL,
+  share_type   TEXT NOT NULL DEFAULT '',
+  share_value  FLOAT NOT NULL DEFAULT 0,
+  share_amount INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (id_template, id_user),
+  FOREIGN KEY (id_template) REFERENCES templates(id) ON DELETE CASCADE,
+  FOREIGN KEY (id_user) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+-- Notes left on billings to keep the context of disputes and unusual
+-- payments, file_id is an optional Telegram photo or document
+CREATE TABLE IF NOT EXISTS billing_notes (
+  id         TEXT PRIMARY KEY,
+  id_billing TEXT NOT NULL,
+  id_user    TEXT REFERENCES users(id) ON DELETE SET NULL,
+  text       TEXT NOT NULL DEFAULT '',
+  file_id    TEXT NOT NULL DEFAULT '',
+  file_type  TEXT NOT NULL DEFAULT '',
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (id_billing) REFERENCES billings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_billing_notes_billing ON billing_notes(id_billing, created_at);
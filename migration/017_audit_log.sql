@@ -0,0 +1,19 @@
+-- Every mutation made through the service, with the command that caused it.
+-- Targets are kept by name too since they may be purged later
+CREATE TABLE IF NOT EXISTS audit_log (
+  id                TEXT PRIMARY KEY,
+  chat_id           INTEGER NOT NULL DEFAULT 0,
+  actor_telegram_id INTEGER NOT NULL DEFAULT 0,
+  command           TEXT NOT NULL DEFAULT '',
+  arguments         TEXT NOT NULL DEFAULT '',
+  action            TEXT NOT NULL,
+  id_billing        TEXT,
+  billing_name      TEXT NOT NULL DEFAULT '',
+  id_user           TEXT,
+  user_name         TEXT NOT NULL DEFAULT '',
+  details           TEXT NOT NULL DEFAULT '',
+  result            TEXT NOT NULL DEFAULT '',
+  created_at        DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_chat ON audit_log(chat_id, created_at);
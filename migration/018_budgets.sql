@@ -0,0 +1,15 @@
+-- Budgets cap the value of the billings created in each period, per category
+-- or for the whole group when category is empty. The alerted columns keep the
+-- last threshold posted so every alert is sent once per period
+CREATE TABLE IF NOT EXISTS budgets (
+  id              TEXT PRIMARY KEY,
+  chat_id         INTEGER NOT NULL DEFAULT 0,
+  category        TEXT NOT NULL DEFAULT '',
+  amount          INTEGER NOT NULL,
+  currency        TEXT NOT NULL DEFAULT 'BRL',
+  period          TEXT NOT NULL DEFAULT 'monthly',
+  alerted_period  DATETIME NOT NULL DEFAULT '0001-01-01 00:00:00+00:00',
+  alerted_percent INTEGER NOT NULL DEFAULT 0,
+  created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (chat_id, category, period)
+);
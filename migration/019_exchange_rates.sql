@@ -0,0 +1,16 @@
+-- Groups settle their balances in a single currency, billings in other
+-- currencies are converted with the rate effective when they were created
+ALTER TABLE groups ADD COLUMN currency TEXT NOT NULL DEFAULT 'BRL';
+
+-- Rates are only inserted, the rate of a pair at a date is the latest one
+-- effective before it
+CREATE TABLE IF NOT EXISTS exchange_rates (
+  id           TEXT PRIMARY KEY,
+  base         TEXT NOT NULL,
+  quote        TEXT NOT NULL,
+  rate         FLOAT NOT NULL,
+  effective_at DATETIME NOT NULL,
+  created_at   DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_pair ON exchange_rates(base, quote, effective_at);
@@ -0,0 +1,11 @@
+-- Conversations keep the guided flows being answered across restarts, one per
+-- chat and user. data holds the answers given so far as a JSON object
+CREATE TABLE IF NOT EXISTS conversations (
+  chat_id     INTEGER NOT NULL,
+  telegram_id INTEGER NOT NULL,
+  flow        TEXT NOT NULL,
+  step        TEXT NOT NULL,
+  data        TEXT NOT NULL DEFAULT '{}',
+  updated_at  DATETIME NOT NULL,
+  PRIMARY KEY (chat_id, telegram_id)
+);
@@ -13,29 +13,473 @@ const (
 	TELEGRAM_ID_EMPTY = 0
 )
 
+// Recurrence defines how often a billing opens a new cycle
+type Recurrence string
+
+const (
+	RecurrenceNone    Recurrence = ""
+	RecurrenceWeekly  Recurrence = "weekly"
+	RecurrenceMonthly Recurrence = "monthly"
+	RecurrenceYearly  Recurrence = "yearly"
+)
+
+// ShareType defines how the amount owed by a user is computed
+type ShareType string
+
+const (
+	ShareEqual   ShareType = ""
+	SharePercent ShareType = "percent"
+	ShareFixed   ShareType = "fixed"
+	ShareWeight  ShareType = "weight"
+)
+
+// PaymentStatus is derived from the ledger and the proofs under review
+type PaymentStatus string
+
+const (
+	PaymentUnpaid        PaymentStatus = "unpaid"
+	PaymentPendingReview PaymentStatus = "pending_review"
+	PaymentPaid          PaymentStatus = "paid"
+)
+
+// ProofStatus is the review state of a payment proof
+type ProofStatus string
+
+const (
+	ProofPendingReview ProofStatus = "pending_review"
+	ProofApproved      ProofStatus = "approved"
+	ProofRejected      ProofStatus = "rejected"
+)
+
+// ProofFileType is the kind of Telegram file attached as payment proof or to
+// a billing note
+type ProofFileType string
+
+const (
+	ProofPhoto    ProofFileType = "photo"
+	ProofDocument ProofFileType = "document"
+)
+
 type User struct {
 	UserID       uuid.UUID
 	TelegramID   int64
 	TelegramName string
 	Admin        bool
+	Reminders    bool
 	CreatedAt    time.Time
+
+	// PIX key and city used by admins to receive payments
+	PixKey  string
+	PixCity string
+
+	// DeletedAt is set when the user is soft deleted
+	DeletedAt time.Time
+}
+
+// Group is a Telegram chat using the bot, billings belong to the group they
+// were created in and users may be members of several groups. Currency is the
+// settlement currency balances and reports are converted to
+type Group struct {
+	ChatID    int64
+	Title     string
+	Currency  string
+	CreatedAt time.Time
+}
+
+// ExchangeRate is how many units of Quote are worth one unit of Base from
+// EffectiveAt on, rates are never edited so they keep the history
+type ExchangeRate struct {
+	ID          uuid.UUID
+	Base        string
+	Quote       string
+	Rate        float64
+	EffectiveAt time.Time
+	CreatedAt   time.Time
 }
 
+// Conversion is a value converted to the settlement currency of the group
+// with the Rate effective when the billing was created
+type Conversion struct {
+	Currency string
+	Value    int64
+	Rate     float64
+}
+
+// Template holds the defaults of a billing created over and over with the
+// same participants, billings created from it may override the value
+type Template struct {
+	ID        uuid.UUID
+	ChatID    int64
+	Name      string
+	Value     int64
+	Currency  string
+	Category  string
+	PayerID   uuid.UUID
+	Users     []TemplateUser
+	CreatedAt time.Time
+}
+
+// TemplateUser is a participant of the template with its split rule
+type TemplateUser struct {
+	UserID   uuid.UUID
+	UserInfo User
+	Share    Share
+}
+
+// Billing values are stored in minor units of its Currency (e.g. cents)
 type Billing struct {
-	ID           uuid.UUID
-	Name         string
-	Value        float64
-	CreatedAt    time.Time
-	ValuePerUser float64
-	Payments     []Payment
+	ID          uuid.UUID
+	Name        string
+	Value       int64
+	Currency    string
+	CreatedAt   time.Time
+	DueAt       time.Time
+	Unallocated int64
+	Payments    []Payment
+
+	// Payer is the user who fronted the money, the creditor of the billing
+	PayerID uuid.UUID
+	Payer   *User
+
+	Category string
+	Tags     []string
+
+	// LateFee is charged from the users still owing after the due date
+	LateFee LateFee
+
+	// DeletedAt is set when the billing is soft deleted
+	DeletedAt time.Time
+
+	// ChatID is the group the billing belongs to, zero for billings created
+	// before groups existed
+	ChatID int64
+
+	// Installments is the number of installments of the plan the billing
+	// belongs to, zero when it is not an installment. Installments are
+	// numbered by Cycle and InstallmentsPaid counts the ones fully paid
+	Installments     int
+	InstallmentsPaid int
+
+	// Recurrence fields, ParentID is the first billing of the series
+	// and holds the rule used to open the following cycles
+	ParentID      uuid.UUID
+	Cycle         int
+	Recurrence    Recurrence
+	RecurrenceDay int
+	PeriodStart   time.Time
+	NextCycleAt   time.Time
+	Cycles        []*Billing
+
+	// Notes are the latest notes left on the billing, oldest first
+	Notes []BillingNote
+
+	// Conversion is the value in the settlement currency of the group, nil
+	// when the currencies match or there is no exchange rate
+	Conversion *Conversion
 }
 
+// Payment is the association of an user with a billing, Paid, PaidAt,
+// PaidAmount and Outstanding are derived from the ledger Entries
 type Payment struct {
 	BillingID uuid.UUID
 	UserID    uuid.UUID
 	Paid      bool
 	PaidAt    time.Time
 	UserInfo  User
+	Share     Share
+	Amount    int64
+
+	PaidAmount  int64
+	Outstanding int64
+	Entries     []PaymentEntry
+
+	// Penalty is the late fee accrued by the payment, AmountDue adds what
+	// is left of it to the outstanding share
+	Penalty   Penalty
+	AmountDue int64
+
+	// Status is pending review while Proof waits for an admin decision
+	Status PaymentStatus
+	Proof  *PaymentProof
+
+	RemindedAt time.Time
+}
+
+// PaymentEntry is a ledger record of an amount paid by an user, reverted
+// payments are recorded as entries with negative amounts. Amount pays the
+// share of the user and Fee the late fees charged over it
+type PaymentEntry struct {
+	ID         uuid.UUID
+	BillingID  uuid.UUID
+	UserID     uuid.UUID
+	Amount     int64
+	Fee        int64
+	Note       string
+	RecordedBy uuid.UUID
+	CreatedAt  time.Time
+}
+
+// PaymentProof is a receipt sent by an user, the Amount is only recorded in
+// the ledger after an admin approves it
+type PaymentProof struct {
+	ID         uuid.UUID
+	BillingID  uuid.UUID
+	UserID     uuid.UUID
+	Amount     int64
+	Note       string
+	FileID     string
+	FileType   ProofFileType
+	Status     ProofStatus
+	Reason     string
+	ReviewedBy uuid.UUID
+	ReviewedAt time.Time
+	CreatedAt  time.Time
+}
+
+// LateFee is the policy applied to payments made after the due date, a Flat
+// fee plus DailyRate percent of the share outstanding on each day late,
+// limited to Cap when it is not zero
+type LateFee struct {
+	Flat      int64
+	DailyRate float64
+	Cap       int64
+}
+
+// Penalty is the late fee accrued by a payment, Due is the part of Fee and
+// Interest not paid yet
+type Penalty struct {
+	DaysLate int
+	Fee      int64
+	Interest int64
+	Paid     int64
+	Due      int64
+}
+
+// Share is the part of a billing assigned to a user, equal shares split
+// what is left after percentages and fixed amounts like a weight of 1.
+// Value holds percentages and weights, Amount holds fixed amounts
+type Share struct {
+	Type   ShareType
+	Value  float64
+	Amount int64
+}
+
+// Budget caps the value of the billings of the group created in each Period,
+// budgets without Category cover every billing of the group. Billings in other
+// currencies are converted to the Currency of the budget, the ones without an
+// exchange rate are not counted
+type Budget struct {
+	ID        uuid.UUID
+	ChatID    int64
+	Category  string
+	Amount    int64
+	Currency  string
+	Period    Recurrence
+	CreatedAt time.Time
+
+	// AlertedPeriod is the start of the period of the last alert posted and
+	// AlertedPercent the threshold it reached
+	AlertedPeriod  time.Time
+	AlertedPercent int
+}
+
+// BudgetStatus is how much of a budget was spent in the period containing the
+// date it was computed for, Percent is rounded down
+type BudgetStatus struct {
+	Budget      *Budget
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Billings    int
+	Spent       int64
+	Percent     int
+}
+
+// BudgetAlert is a budget that reached the Threshold percent of its amount
+// without an alert posted in the period
+type BudgetAlert struct {
+	Status    BudgetStatus
+	Threshold int
+}
+
+// Conversation is a guided flow a user is answering in a chat, each user has
+// a single conversation per chat. Step is the question waiting for an answer
+// and Data keeps the answers given so far
+type Conversation struct {
+	ChatID     int64
+	TelegramID int64
+	Flow       string
+	Step       string
+	Data       map[string]string
+	UpdatedAt  time.Time
+}
+
+// BillingUpdate holds the fields to change in a billing, nil fields are kept
+type BillingUpdate struct {
+	Name     *string
+	Value    *int64
+	DueAt    *time.Time
+	PayerID  *uuid.UUID
+	Category *string
+	Tags     *[]string
+	LateFee  *LateFee
+}
+
+// BillingChange records an edit of a billing field, values are kept as text
+// as they were shown to the user. ActorID is empty when the user was deleted
+type BillingChange struct {
+	ID        uuid.UUID
+	BillingID uuid.UUID
+	Field     string
+	OldValue  string
+	NewValue  string
+	ActorID   uuid.UUID
+	Actor     *User
+	CreatedAt time.Time
+}
+
+// BillingNote is a comment left on a billing, FileID is an optional photo or
+// document attached to it. AuthorID is empty when the user was deleted
+type BillingNote struct {
+	ID        uuid.UUID
+	BillingID uuid.UUID
+	AuthorID  uuid.UUID
+	Author    *User
+	Text      string
+	FileID    string
+	FileType  ProofFileType
+	CreatedAt time.Time
+}
+
+// AuditActor is who runs the command that mutates the state, it is carried by
+// the context of the service calls
+type AuditActor struct {
+	ChatID     int64
+	TelegramID int64
+	Command    string
+	Arguments  string
+}
+
+// AuditEntry records a mutation made through the service and its Result, the
+// error message or "ok". Entries without Command were made by the bot itself,
+// like opening billing cycles
+type AuditEntry struct {
+	ID              uuid.UUID
+	ChatID          int64
+	ActorTelegramID int64
+	Command         string
+	Arguments       string
+	Action          string
+	BillingID       uuid.UUID
+	BillingName     string
+	UserID          uuid.UUID
+	UserName        string
+	Details         string
+	Result          string
+	CreatedAt       time.Time
+}
+
+// AuditFilter restricts the audit entries listed to a group, UserID matches
+// entries targeting the user and TelegramID the ones made by it
+type AuditFilter struct {
+	ChatID     int64
+	BillingID  uuid.UUID
+	UserID     uuid.UUID
+	TelegramID int64
+	Limit      int
+}
+
+// BillingFilter restricts the billings listed, empty fields match everything.
+// Unpaid billings have less paid in the ledger than their value and UserID
+// matches billings the user is associated with
+type BillingFilter struct {
+	ChatID   int64
+	Category string
+	Tag      string
+	Unpaid   bool
+	UserID   uuid.UUID
+}
+
+// Balance is the net position of an user in a currency, positive amounts
+// must be received and negative amounts must be paid
+type Balance struct {
+	User     User
+	Currency string
+	Amount   int64
+}
+
+// Transfer is a payment that must be made to settle balances
+type Transfer struct {
+	From     User
+	To       User
+	Currency string
+	Amount   int64
+}
+
+// PixCharge is the PIX payload an user must pay to the Receiver of a billing
+type PixCharge struct {
+	Billing  *Billing
+	Debtor   User
+	Receiver User
+	Amount   int64
+	Payload  string
+}
+
+// BillingImportRow is a line of a billing import file, Billing holds the
+// billing to create when the line is valid
+type BillingImportRow struct {
+	Line     int
+	Name     string
+	Value    string
+	Due      string
+	Users    []string
+	Currency string
+
+	Billing *Billing
+	Error   string
+}
+
+// Report summarizes the billings created in a month
+type Report struct {
+	Period   time.Time
+	Billings int
+	Totals   []ReportTotal
+	Users    []ReportUser
+	Overdue  []ReportOverdue
+}
+
+// ReportTotal is the value of the billings of a currency in the period
+type ReportTotal struct {
+	Currency    string
+	Value       int64
+	Paid        int64
+	Outstanding int64
+}
+
+// ReportUser is how much an user owes and has paid in a currency
+type ReportUser struct {
+	User     User
+	Currency string
+	Owed     int64
+	Paid     int64
+}
+
+// ReportOverdue is an unpaid payment past its due date
+type ReportOverdue struct {
+	Billing     string
+	DueAt       time.Time
+	User        User
+	Currency    string
+	Outstanding int64
+}
+
+// Reminder is an unpaid payment of a billing with due date
+type Reminder struct {
+	Billing Billing
+	Payment Payment
+
+	// Group is the chat the billing belongs to, nil for billings created
+	// before groups existed
+	Group *Group
 }
 
 type Midia struct {